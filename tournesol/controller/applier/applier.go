@@ -0,0 +1,160 @@
+// Package applier applies generated file updates directly to the live
+// cluster via Kubernetes server-side apply, as an alternative (or
+// supplement) to opening a pull request through gh-service. This is for
+// urgent production fires and for clusters with no GitOps sync in place.
+package applier
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// FieldManager identifies this controller's writes to the API server for
+// server-side apply's conflict detection and field ownership tracking.
+const FieldManager = "prof-tournesol"
+
+// FileUpdate is a proposed change to a single file's contents, mirroring the
+// controller's own FileUpdate type (to keep this package dependency-free of
+// package main).
+type FileUpdate struct {
+	Path    string
+	Content string
+}
+
+// AppliedObject records one object successfully applied via server-side
+// apply, so the caller can annotate the triggering Result with what changed.
+type AppliedObject struct {
+	GroupVersionKind string `json:"group_version_kind"`
+	Namespace        string `json:"namespace,omitempty"`
+	Name             string `json:"name"`
+	ResourceVersion  string `json:"resource_version"`
+}
+
+// dangerousKinds must be explicitly allowlisted via New's
+// allowedDangerousKinds before Apply will touch them: a bad AI-generated
+// manifest for one of these can take down the whole cluster rather than
+// just the target workload.
+var dangerousKinds = map[string]bool{
+	"Namespace":                true,
+	"CustomResourceDefinition": true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"Role":                     true,
+	"RoleBinding":              true,
+}
+
+// wellKnownGVRs maps the GroupVersionKind of manifests the AI endpoint and
+// the remediation strategies typically produce to their GVR. Apply resolves
+// resources this way instead of via cluster discovery, which keeps SSA mode
+// dependency-light; an unmapped kind fails closed rather than guessing a
+// pluralization.
+var wellKnownGVRs = map[schema.GroupVersionKind]schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Kind: "Deployment"}:  {Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"}: {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"}:   {Group: "apps", Version: "v1", Resource: "daemonsets"},
+
+	{Group: "", Version: "v1", Kind: "Pod"}:                   {Group: "", Version: "v1", Resource: "pods"},
+	{Group: "", Version: "v1", Kind: "Service"}:               {Group: "", Version: "v1", Resource: "services"},
+	{Group: "", Version: "v1", Kind: "ConfigMap"}:             {Group: "", Version: "v1", Resource: "configmaps"},
+	{Group: "", Version: "v1", Kind: "Secret"}:                {Group: "", Version: "v1", Resource: "secrets"},
+	{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}: {Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+	{Group: "", Version: "v1", Kind: "Namespace"}:             {Group: "", Version: "v1", Resource: "namespaces"},
+
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}: {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+
+	{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}: {Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}:               {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}:        {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}:        {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}: {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+}
+
+// Applier applies FileUpdates to the live cluster via server-side apply.
+type Applier struct {
+	client                dynamic.Interface
+	allowedDangerousKinds map[string]bool
+}
+
+// New creates an Applier using client for API access. allowedDangerousKinds
+// lifts the dangerousKinds guard for exactly the kinds named (e.g.
+// "ClusterRole"); every other dangerous kind is still rejected.
+func New(client dynamic.Interface, allowedDangerousKinds []string) *Applier {
+	allowed := make(map[string]bool, len(allowedDangerousKinds))
+	for _, kind := range allowedDangerousKinds {
+		allowed[kind] = true
+	}
+	return &Applier{client: client, allowedDangerousKinds: allowed}
+}
+
+// Apply parses each update's content as a Kubernetes manifest and applies it
+// via server-side apply (types.ApplyPatchType) under FieldManager, forcing
+// ownership conflicts since this controller's updates should win over a
+// stale GitOps sync. It returns every object applied before an error, if
+// any, so the caller can still record partial progress.
+func (a *Applier) Apply(ctx context.Context, namespace string, updates []FileUpdate) ([]AppliedObject, error) {
+	applied := make([]AppliedObject, 0, len(updates))
+
+	for _, update := range updates {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(update.Content), &obj.Object); err != nil {
+			return applied, fmt.Errorf("failed to parse %s as a Kubernetes manifest: %w", update.Path, err)
+		}
+
+		gvk := obj.GroupVersionKind()
+		if gvk.Empty() {
+			return applied, fmt.Errorf("%s has no apiVersion/kind, refusing to apply", update.Path)
+		}
+
+		kind := obj.GetKind()
+		if dangerousKinds[kind] && !a.allowedDangerousKinds[kind] {
+			return applied, fmt.Errorf("refusing to apply %s: kind %q is not in APPLY_ALLOWED_DANGEROUS_KINDS", update.Path, kind)
+		}
+
+		gvr, ok := wellKnownGVRs[gvk]
+		if !ok {
+			return applied, fmt.Errorf("%s: no known resource mapping for kind %q", update.Path, kind)
+		}
+
+		if obj.GetNamespace() == "" && namespace != "" {
+			obj.SetNamespace(namespace)
+		}
+
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return applied, fmt.Errorf("failed to marshal %s for server-side apply: %w", update.Path, err)
+		}
+
+		var resourceClient dynamic.ResourceInterface
+		if obj.GetNamespace() != "" {
+			resourceClient = a.client.Resource(gvr).Namespace(obj.GetNamespace())
+		} else {
+			resourceClient = a.client.Resource(gvr)
+		}
+
+		force := true
+		result, err := resourceClient.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+			FieldManager: FieldManager,
+			Force:        &force,
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply %s: %w", update.Path, err)
+		}
+
+		applied = append(applied, AppliedObject{
+			GroupVersionKind: gvk.String(),
+			Namespace:        result.GetNamespace(),
+			Name:             result.GetName(),
+			ResourceVersion:  result.GetResourceVersion(),
+		})
+	}
+
+	return applied, nil
+}
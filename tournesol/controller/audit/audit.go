@@ -0,0 +1,59 @@
+// Package audit persists a record of every AI round-trip (and the local
+// fallback path that replaces it) for postmortem review and for refining
+// future prompts. Implementations must be safe for concurrent use.
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Diagnostic carries the K8sGPT-reported problem for a single resource,
+// mirroring the controller's own diagnostic type without importing it (to
+// keep this package dependency-free of package main).
+type Diagnostic struct {
+	Name      string
+	Kind      string
+	Namespace string
+	Error     string
+	Solution  string
+}
+
+// FileUpdate is a proposed change to a single file's contents, mirroring the
+// controller's own FileUpdate type.
+type FileUpdate struct {
+	Path    string
+	Content string
+}
+
+// Envelope is the full record of one Result's AI round-trip, the proposed
+// patch, and what became of it.
+type Envelope struct {
+	Diagnostic      Diagnostic   `json:"diagnostic"`
+	CorrelationID   string       `json:"correlation_id"`
+	Prompt          string       `json:"prompt"`
+	AIResponse      string       `json:"ai_response"`
+	UsedFallback    bool         `json:"used_fallback"`
+	EndpointHealthy bool         `json:"endpoint_healthy"`
+	FileUpdates     []FileUpdate `json:"file_updates"`
+	PRUrl           string       `json:"pr_url,omitempty"`
+	Timestamp       time.Time    `json:"timestamp"`
+}
+
+// Sink records an Envelope and the raw content of its file updates for a
+// single K8sGPT Result, keyed by namespace and result name.
+type Sink interface {
+	// Record persists envelope, plus each of its FileUpdates as its own
+	// object/file for easy diffing, under a key scoped to namespace and
+	// resultName.
+	Record(ctx context.Context, namespace, resultName string, envelope Envelope) error
+}
+
+// NoopSink discards every envelope. It's the default when no audit backend
+// is configured.
+type NoopSink struct{}
+
+// Record implements Sink.
+func (NoopSink) Record(context.Context, string, string, Envelope) error {
+	return nil
+}
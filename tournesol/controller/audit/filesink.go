@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSink is a local-filesystem Sink for development, mirroring the layout
+// an S3Sink would use: baseDir/<namespace>/<resultName>/<timestamp>.json for
+// the envelope, plus baseDir/<namespace>/<resultName>/<timestamp>/<path> for
+// each file update.
+type FileSink struct {
+	baseDir string
+}
+
+// NewFileSink creates a FileSink rooted at baseDir, creating it if needed.
+func NewFileSink(baseDir string) (*FileSink, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	return &FileSink{baseDir: baseDir}, nil
+}
+
+// Record implements Sink.
+func (s *FileSink) Record(_ context.Context, namespace, resultName string, envelope Envelope) error {
+	dir := filepath.Join(s.baseDir, namespace, resultName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create audit result directory: %w", err)
+	}
+
+	stamp := envelope.Timestamp.UTC().Format("20060102T150405.000Z")
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit envelope: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, stamp+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write audit envelope: %w", err)
+	}
+
+	filesDir := filepath.Join(dir, stamp)
+	for _, update := range envelope.FileUpdates {
+		dest, err := safeJoin(filesDir, update.Path)
+		if err != nil {
+			return fmt.Errorf("refusing to write audit file update %s: %w", update.Path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create audit files directory: %w", err)
+		}
+		if err := os.WriteFile(dest, []byte(update.Content), 0644); err != nil {
+			return fmt.Errorf("failed to write audit file update %s: %w", update.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// safeJoin joins rel onto dir after confirming the cleaned result stays
+// within dir. update.Path comes from the AI endpoint's response, so it must
+// be treated as untrusted input: without this check a crafted path like
+// "../../../../etc/cron.d/x" would let Record write outside baseDir.
+func safeJoin(dir, rel string) (string, error) {
+	dest := filepath.Join(dir, rel)
+	if dest != dir && !strings.HasPrefix(dest, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes audit files directory", rel)
+	}
+	return dest, nil
+}
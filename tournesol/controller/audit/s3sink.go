@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Sink is a Sink backed by an S3-compatible object store (AWS S3, MinIO,
+// ...), writing each envelope and its file updates as separate objects under
+// <namespace>/<resultName>/<timestamp>(.json|/<path>).
+type S3Sink struct {
+	client *minio.Client
+	bucket string
+}
+
+// S3Config holds the connection details for NewS3Sink.
+type S3Config struct {
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// NewS3Sink creates an S3Sink connected to cfg.Endpoint, creating cfg.Bucket
+// if it doesn't already exist.
+func NewS3Sink(ctx context.Context, cfg S3Config) (*S3Sink, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check audit bucket %q: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create audit bucket %q: %w", cfg.Bucket, err)
+		}
+	}
+
+	return &S3Sink{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Record implements Sink.
+func (s *S3Sink) Record(ctx context.Context, namespace, resultName string, envelope Envelope) error {
+	stamp := envelope.Timestamp.UTC().Format("20060102T150405.000Z")
+	prefix := fmt.Sprintf("%s/%s/%s", namespace, resultName, stamp)
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit envelope: %w", err)
+	}
+	if err := s.putObject(ctx, prefix+".json", data, "application/json"); err != nil {
+		return fmt.Errorf("failed to upload audit envelope: %w", err)
+	}
+
+	for _, update := range envelope.FileUpdates {
+		key, err := safeJoinKey(prefix, update.Path)
+		if err != nil {
+			return fmt.Errorf("refusing to upload audit file update %s: %w", update.Path, err)
+		}
+		if err := s.putObject(ctx, key, []byte(update.Content), "application/yaml"); err != nil {
+			return fmt.Errorf("failed to upload audit file update %s: %w", update.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// putObject uploads data to key in the configured bucket.
+func (s *S3Sink) putObject(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// safeJoinKey joins rel onto prefix after confirming the cleaned result
+// stays under prefix, mirroring FileSink's safeJoin for object keys instead
+// of filesystem paths. update.Path comes from the AI endpoint's response, so
+// it must be treated as untrusted input: without this check a crafted path
+// like "../other-result/envelope.json" would let Record overwrite another
+// namespace's or result's audit objects in the bucket.
+func safeJoinKey(prefix, rel string) (string, error) {
+	key := path.Join(prefix, rel)
+	if key != prefix && !strings.HasPrefix(key, prefix+"/") {
+		return "", fmt.Errorf("path %q escapes audit key prefix", rel)
+	}
+	return key, nil
+}
@@ -0,0 +1,181 @@
+package dedupe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var configMapGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+
+// maxConfigMapConflictRetries bounds how many attempts update makes in all
+// while it keeps losing races against other HA replicas, in the same style
+// as the gh-service optimistic-concurrency retry loop.
+const maxConfigMapConflictRetries = 5
+
+// ConfigMapStore is a Store backed by a Kubernetes ConfigMap, shared by all
+// replicas of an HA controller deployment. Each data key is the uid/resource
+// version pair and its value is the JSON-encoded entry.
+type ConfigMapStore struct {
+	client    dynamic.Interface
+	namespace string
+	name      string
+	mu        sync.Mutex
+}
+
+// NewConfigMapStore creates a ConfigMapStore that reads/writes the ConfigMap
+// name in namespace, via the existing dynamic client used for Result resources.
+func NewConfigMapStore(client dynamic.Interface, namespace, name string) *ConfigMapStore {
+	return &ConfigMapStore{client: client, namespace: namespace, name: name}
+}
+
+// SeenSince implements Store.
+func (s *ConfigMapStore) SeenSince(uid, resourceVersion string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.readData(context.Background())
+	if err != nil {
+		return false, err
+	}
+
+	raw, ok := data[entryKey(uid, resourceVersion)]
+	if !ok {
+		return false, nil
+	}
+
+	var e entry
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return false, nil
+	}
+	return time.Now().Before(e.ExpiresAt), nil
+}
+
+// Mark implements Store.
+func (s *ConfigMapStore) Mark(uid, resourceVersion string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+
+	e := entry{ResourceVersion: resourceVersion, ExpiresAt: time.Now().Add(ttl)}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dedupe entry: %w", err)
+	}
+
+	return s.update(ctx, func(data map[string]string) {
+		data[entryKey(uid, resourceVersion)] = string(raw)
+	})
+}
+
+// Compact removes expired entries from the ConfigMap.
+func (s *ConfigMapStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	return s.update(context.Background(), func(data map[string]string) {
+		for key, raw := range data {
+			var e entry
+			if err := json.Unmarshal([]byte(raw), &e); err != nil || now.After(e.ExpiresAt) {
+				delete(data, key)
+			}
+		}
+	})
+}
+
+// readData fetches the ConfigMap's data map, returning an empty map if the
+// ConfigMap doesn't exist yet.
+func (s *ConfigMapStore) readData(ctx context.Context) (map[string]string, error) {
+	cm, err := s.client.Resource(configMapGVR).Namespace(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dedupe configmap: %w", err)
+	}
+
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	if data == nil {
+		data = map[string]string{}
+	}
+	return data, nil
+}
+
+// update applies mutate to the ConfigMap's data map and persists it, creating
+// the ConfigMap if it doesn't exist yet. Under HA, two replicas can race to
+// Update/Create the same ConfigMap at once; the loser retries against
+// whatever the winner left behind (re-Get, reapply mutate, try again) rather
+// than dropping the mark, up to maxConfigMapConflictRetries attempts.
+func (s *ConfigMapStore) update(ctx context.Context, mutate func(data map[string]string)) error {
+	var lastErr error
+	for attempt := 0; attempt < maxConfigMapConflictRetries; attempt++ {
+		conflict, err := s.tryUpdate(ctx, mutate)
+		if err == nil {
+			return nil
+		}
+		if !conflict {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("giving up on dedupe configmap update after %d conflicts: %w", maxConfigMapConflictRetries, lastErr)
+}
+
+// tryUpdate makes a single attempt at the Get-mutate-Update/Create cycle. It
+// reports conflict=true for errors worth retrying (another replica updated
+// or created the ConfigMap first), so the caller can re-Get and reapply
+// mutate against the latest state instead of clobbering it.
+func (s *ConfigMapStore) tryUpdate(ctx context.Context, mutate func(data map[string]string)) (conflict bool, err error) {
+	cm, err := s.client.Resource(configMapGVR).Namespace(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		data := map[string]string{}
+		mutate(data)
+
+		cm = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      s.name,
+				"namespace": s.namespace,
+			},
+		}}
+		if err := unstructured.SetNestedStringMap(cm.Object, data, "data"); err != nil {
+			return false, fmt.Errorf("failed to set dedupe configmap data: %w", err)
+		}
+
+		_, err := s.client.Resource(configMapGVR).Namespace(s.namespace).Create(ctx, cm, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return true, err
+		}
+		return false, err
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get dedupe configmap: %w", err)
+	}
+
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	if data == nil {
+		data = map[string]string{}
+	}
+	mutate(data)
+
+	if err := unstructured.SetNestedStringMap(cm.Object, data, "data"); err != nil {
+		return false, fmt.Errorf("failed to set dedupe configmap data: %w", err)
+	}
+
+	_, err = s.client.Resource(configMapGVR).Namespace(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return true, err
+	}
+	return false, err
+}
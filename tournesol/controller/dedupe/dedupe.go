@@ -0,0 +1,30 @@
+// Package dedupe tracks which K8sGPT Result resources have already been
+// processed so that controller restarts don't re-open duplicate PRs when the
+// informer replays existing resources.
+package dedupe
+
+import "time"
+
+// Store records which (uid, resourceVersion) pairs have already been
+// processed. Implementations must be safe for concurrent use.
+type Store interface {
+	// SeenSince reports whether uid has already been marked as processed at
+	// exactly resourceVersion, and the mark has not yet expired. A mark for
+	// any other resourceVersion of the same uid does not count.
+	SeenSince(uid, resourceVersion string) (bool, error)
+	// Mark records uid/resourceVersion as processed, expiring after ttl.
+	Mark(uid, resourceVersion string, ttl time.Duration) error
+}
+
+// Compactable is implemented by stores that support removing expired entries
+// outside of Mark/SeenSince, so the controller can run it on a timer.
+type Compactable interface {
+	Compact() error
+}
+
+// DefaultTTL is used when the caller has no specific retention requirement.
+const DefaultTTL = 30 * 24 * time.Hour
+
+func entryKey(uid, resourceVersion string) string {
+	return uid + "@" + resourceVersion
+}
@@ -0,0 +1,128 @@
+package dedupe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// entry is the on-disk representation of a single processed resource.
+type entry struct {
+	ResourceVersion string    `json:"resourceVersion"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// FileStore is a JSON-file-backed Store for single-replica deployments. It
+// keeps the whole set in memory and rewrites the file on every Mark, which is
+// fine at the scale of K8sGPT Result resources.
+type FileStore struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]entry
+	loaded  bool
+}
+
+// NewFileStore creates a FileStore backed by the JSON file at path, creating
+// its parent directory if needed.
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dedupe store directory: %w", err)
+	}
+	return &FileStore{path: path}, nil
+}
+
+// ensureLoaded reads the store file into memory the first time it's needed.
+// Callers must hold s.mu.
+func (s *FileStore) ensureLoaded() error {
+	if s.loaded {
+		return nil
+	}
+
+	s.entries = make(map[string]entry)
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dedupe store: %w", err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			return fmt.Errorf("failed to parse dedupe store: %w", err)
+		}
+	}
+
+	s.loaded = true
+	return nil
+}
+
+// save writes the in-memory entries back to disk atomically.
+func (s *FileStore) save() error {
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dedupe store: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write dedupe store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// SeenSince implements Store.
+func (s *FileStore) SeenSince(uid, resourceVersion string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureLoaded(); err != nil {
+		return false, err
+	}
+
+	e, ok := s.entries[entryKey(uid, resourceVersion)]
+	if !ok {
+		return false, nil
+	}
+	return time.Now().Before(e.ExpiresAt), nil
+}
+
+// Mark implements Store.
+func (s *FileStore) Mark(uid, resourceVersion string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureLoaded(); err != nil {
+		return err
+	}
+
+	s.entries[entryKey(uid, resourceVersion)] = entry{
+		ResourceVersion: resourceVersion,
+		ExpiresAt:       time.Now().Add(ttl),
+	}
+	return s.save()
+}
+
+// Compact removes expired entries and rewrites the store file.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureLoaded(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for key, e := range s.entries {
+		if now.After(e.ExpiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	return s.save()
+}
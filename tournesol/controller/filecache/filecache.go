@@ -0,0 +1,163 @@
+// Package filecache provides a small on-disk, SHA-keyed cache for blob
+// content fetched from the GitHub Git Blobs API. Since a blob's SHA is a
+// content hash, a cache hit never needs revalidation: if the SHA is present
+// on disk it is the exact content GitHub would return.
+package filecache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cache stores blob content on disk under ${baseDir}/xx/xxxxx..., where xx is
+// the first two characters of the blob SHA, to keep any one directory small.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	mu      sync.Mutex
+}
+
+// New creates a Cache rooted at ${XDG_CACHE_HOME:-/tmp}/prof-tournesol/blobs.
+// Entries older than ttl are treated as misses, and Compact enforces maxSize
+// (in bytes) by evicting the oldest entries first.
+func New(ttl time.Duration, maxSize int64) (*Cache, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		base = "/tmp"
+	}
+	dir := filepath.Join(base, "prof-tournesol", "blobs")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create file cache directory %s: %w", dir, err)
+	}
+
+	return &Cache{dir: dir, ttl: ttl, maxSize: maxSize}, nil
+}
+
+// path returns the on-disk path for a given blob SHA, sharded by its first
+// two characters.
+func (c *Cache) path(sha string) (string, error) {
+	if len(sha) < 2 {
+		return "", fmt.Errorf("invalid blob sha %q", sha)
+	}
+	return filepath.Join(c.dir, sha[:2], sha), nil
+}
+
+// Get returns the cached content for sha, or (nil, false) on a miss or
+// expired entry.
+func (c *Cache) Get(sha string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path, err := c.path(sha)
+	if err != nil {
+		return nil, false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores data under sha, overwriting any existing entry.
+func (c *Cache) Put(sha string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path, err := c.path(sha)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create shard directory for %s: %w", sha, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry for %s: %w", sha, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// cacheEntry is used internally by Compact to sort entries by age.
+type cacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Compact removes entries older than the configured TTL, then, if the cache
+// still exceeds maxSize, evicts the oldest remaining entries until it fits.
+func (c *Cache) Compact() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entries []cacheEntry
+	var total int64
+
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) == ".tmp" {
+			return nil
+		}
+
+		if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+			return os.Remove(path)
+		}
+
+		entries = append(entries, cacheEntry{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk file cache: %w", err)
+	}
+
+	if c.maxSize <= 0 || total <= c.maxSize {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	for _, entry := range entries {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(entry.path); err != nil {
+			continue
+		}
+		total -= entry.size
+	}
+
+	return nil
+}
+
+// StartCompactor runs Compact on the given interval until stopCh is closed.
+func (c *Cache) StartCompactor(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = c.Compact()
+		case <-stopCh:
+			return
+		}
+	}
+}
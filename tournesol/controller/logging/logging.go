@@ -0,0 +1,83 @@
+// Package logging builds the controller's structured logger and threads it
+// (along with a per-resource correlation ID) through a context.Context, so
+// every function already passing ctx around for HTTP timeouts picks up
+// request-scoped logging for free.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New builds a *zap.SugaredLogger at level (e.g. "debug", "info", "warn",
+// "error"). json selects the production JSON encoder, used when running
+// in-cluster; otherwise a human-readable console encoder is used.
+func New(level string, json bool) (*zap.SugaredLogger, error) {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	var cfg zap.Config
+	if json {
+		cfg = zap.NewProductionConfig()
+	} else {
+		cfg = zap.NewDevelopmentConfig()
+	}
+	cfg.Level = zap.NewAtomicLevelAt(zapLevel)
+
+	logger, err := cfg.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %w", err)
+	}
+
+	return logger.Sugar(), nil
+}
+
+// NewCorrelationID returns a short random hex string to scope the logs, PR
+// body, and AI request headers for a single Result's processing.
+func NewCorrelationID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unavailable"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+type loggerKey struct{}
+type correlationIDKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, retrievable with
+// FromContext.
+func WithLogger(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by WithLogger, or a no-op
+// logger if none was stored.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
+		return logger
+	}
+	return zap.NewNop().Sugar()
+}
+
+// WithCorrelationID returns a copy of ctx carrying id, retrievable with
+// CorrelationID.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored in ctx by
+// WithCorrelationID, or "" if none was stored.
+func CorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
@@ -1,29 +1,43 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"go.uber.org/zap"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	dynamicinformer "k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/applier"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/audit"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/dedupe"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/filecache"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/strategies"
 )
 
 type diagnostic struct {
@@ -39,10 +53,6 @@ type aiPayload struct {
 	Solution string            `json:"solution"`
 }
 
-// ProcessedResources tracks which resources have been processed to avoid duplicates
-var processedResources = make(map[string]bool)
-var processedMutex sync.Mutex
-
 // FileUpdate represents the format expected for file updates
 type FileUpdate struct {
 	Path    string `json:"path"`
@@ -61,11 +71,80 @@ var (
 	aiTimeoutSecs = getEnvIntOrDefault("AI_TIMEOUT_SECONDS", 60)
 	aiMaxRetries  = getEnvIntOrDefault("AI_MAX_RETRIES", 3)
 	aiHealthCheck = getEnvBoolOrDefault("AI_HEALTH_CHECK", true)
+	aiStream      = getEnvBoolOrDefault("AI_STREAM", true)
 	useFallback   = getEnvBoolOrDefault("USE_FALLBACK", true)
 	httpClient    = &http.Client{Timeout: time.Duration(aiTimeoutSecs) * time.Second}
 	githubApiUrl  = "https://api.github.com"
 	ghServiceUrl  = getEnvOrDefault("GH_SERVICE_URL", "http://gh-service.tournesol:80")
 
+	// Blob cache configuration
+	fileCacheTTLSecs   = getEnvIntOrDefault("FILE_CACHE_TTL_SECONDS", 3600)
+	fileCacheMaxSizeMB = getEnvIntOrDefault("FILE_CACHE_MAX_SIZE_MB", 256)
+	fileCacheWorkers   = getEnvIntOrDefault("FILE_CACHE_WORKERS", 8)
+
+	blobCache     *filecache.Cache
+	blobCacheOnce sync.Once
+
+	// Dedupe store configuration
+	dedupeBackend            = getEnvOrDefault("DEDUPE_BACKEND", "file") // "file" or "configmap"
+	dedupeFilePath           = getEnvOrDefault("DEDUPE_FILE_PATH", "/var/lib/prof-tournesol/dedupe.json")
+	dedupeConfigMapName      = getEnvOrDefault("DEDUPE_CONFIGMAP_NAME", "prof-tournesol-dedupe")
+	dedupeTTL                = time.Duration(getEnvIntOrDefault("DEDUPE_TTL_DAYS", 30)) * 24 * time.Hour
+	dedupeCompactionInterval = time.Duration(getEnvIntOrDefault("DEDUPE_COMPACTION_INTERVAL_MINUTES", 60)) * time.Minute
+	dedupeNamespace          = getEnvOrDefault("DEDUPE_NAMESPACE", "k8sgpt-operator-system")
+	dedupeStore              dedupe.Store
+
+	// Remediation strategy configuration
+	remediationStrategies = getEnvOrDefault("REMEDIATION_STRATEGIES", strategies.DefaultOrder)
+	strategyRegistry      *strategies.Registry
+	strategyRegistryOnce  sync.Once
+
+	// Audit sink configuration. "s3" writes to an S3/MinIO bucket for
+	// postmortem review and prompt refinement, "file" writes to local disk
+	// for development, and "none" (the default) disables auditing.
+	auditBackend              = getEnvOrDefault("AUDIT_BACKEND", "none") // "none", "file", or "s3"
+	auditDir                  = getEnvOrDefault("AUDIT_DIR", "/var/lib/prof-tournesol/audit")
+	auditEndpoint             = getEnvOrDefault("AUDIT_ENDPOINT", "")
+	auditBucket               = getEnvOrDefault("AUDIT_BUCKET", "prof-tournesol-audit")
+	auditAccessKey            = getEnvOrDefault("AUDIT_ACCESS_KEY", "")
+	auditSecretKey            = getEnvOrDefault("AUDIT_SECRET_KEY", "")
+	auditUseSSL               = getEnvBoolOrDefault("AUDIT_USE_SSL", true)
+	auditSink      audit.Sink = audit.NoopSink{}
+
+	// Logging configuration. JSON output is used automatically when running
+	// in-cluster (detected via the standard KUBERNETES_SERVICE_HOST env var),
+	// since that's consumed by a log pipeline rather than a human terminal.
+	logLevel = getEnvOrDefault("LOG_LEVEL", "info")
+	logJSON  = os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+	logger   *zap.SugaredLogger
+
+	// logLevelFlag, parsed in main(), overrides LOG_LEVEL when set, for
+	// one-off runs and local debugging where a flag is less friction than
+	// an env var.
+	logLevelFlag = flag.String("log-level", "", "log level: debug, info, warn, or error (overrides LOG_LEVEL)")
+
+	// Apply mode configuration. "pr" (the default) opens a PR via gh-service,
+	// "ssa" applies file updates directly to the live cluster via Kubernetes
+	// server-side apply, and "both" does both. SSA is for urgent production
+	// fires and for clusters with no GitOps sync to pick up a merged PR.
+	applyMode                  = getEnvOrDefault("APPLY_MODE", "pr") // "pr", "ssa", or "both"
+	applyAllowedDangerousKinds = getEnvOrDefault("APPLY_ALLOWED_DANGEROUS_KINDS", "")
+	resourceApplier            *applier.Applier
+	dynamicClient              dynamic.Interface
+
+	// applyModeFlag, parsed in main(), overrides APPLY_MODE when set, for
+	// one-off runs and local debugging where a flag is less friction than
+	// an env var.
+	applyModeFlag = flag.String("apply-mode", "", "apply mode: pr, ssa, or both (overrides APPLY_MODE)")
+
+	// resultGVR is the GVR for the K8sGPT Result CRD this controller watches.
+	resultGVR = schema.GroupVersionResource{Group: "core.k8sgpt.ai", Version: "v1alpha1", Resource: "results"}
+
+	// githubActionsSummaryWritten tracks whether the Markdown summary table header
+	// has already been appended to GITHUB_STEP_SUMMARY for this process.
+	githubActionsSummaryWritten = false
+	githubActionsSummaryMutex   sync.Mutex
+
 	// Health check state
 	endpointHealthy     = false
 	endpointHealthMutex = &sync.Mutex{}
@@ -104,21 +183,35 @@ func handleResult(obj *unstructured.Unstructured) {
 	// Get unique identifier for this resource to avoid duplicate processing
 	uid, _, _ := unstructured.NestedString(obj.Object, "metadata", "uid")
 	name, _, _ := unstructured.NestedString(obj.Object, "metadata", "name")
+	resultNamespace, _, _ := unstructured.NestedString(obj.Object, "metadata", "namespace")
+	if resultNamespace == "" {
+		resultNamespace = dedupeNamespace
+	}
 	resourceID := uid
 	if resourceID == "" {
 		resourceID = name // Fallback to name if UID isn't available
 	}
+	resourceVersion, _, _ := unstructured.NestedString(obj.Object, "metadata", "resourceVersion")
 
-	// Check if we've already processed this resource
-	processedMutex.Lock()
-	if processed, exists := processedResources[resourceID]; exists && processed {
-		processedMutex.Unlock()
-		fmt.Printf("Skipping already processed resource: %s\n", resourceID)
-		return
+	// Scope a sub-logger to this resource for the rest of processing, carrying
+	// a correlation ID that also flows into the gh-service PR body and the AI
+	// request headers.
+	correlationID := logging.NewCorrelationID()
+	log := logger.With("resource_id", resourceID, "correlation_id", correlationID)
+
+	// Check if we've already processed this resource. This is consulted before
+	// any GitHub or AI work, and only Mark()'d once the file updates have been
+	// delivered (PR, GitHub Actions output, or server-side apply), so a crash or
+	// API failure mid-processing gets retried on the next informer event.
+	if dedupeStore != nil {
+		seen, err := dedupeStore.SeenSince(resourceID, resourceVersion)
+		if err != nil {
+			log.Warnw("dedupe store lookup failed, processing anyway", "error", err)
+		} else if seen {
+			log.Infow("skipping already processed resource", "resource_version", resourceVersion)
+			return
+		}
 	}
-	// Mark as being processed
-	processedResources[resourceID] = true
-	processedMutex.Unlock()
 
 	var diag diagnostic
 	// Get the resource name which might include namespace in format "namespace/name"
@@ -153,258 +246,479 @@ func handleResult(obj *unstructured.Unstructured) {
 		diag.solution = strings.TrimPrefix(parts[1], "Solution: ")
 	}
 
-	// Print the diagnostic information
-	fmt.Printf("[%s]\nKind: %s\nNamespace: %s\nError: %s\nSolution: %s\n",
-		resourceName, diag.kind, diag.namespace, diag.error, diag.solution)
+	// Scope the logger to this diagnostic now that namespace/name/kind are known.
+	log = log.With("namespace", diag.namespace, "name", diag.name, "kind", diag.kind)
+	log.Infow("diagnostic received", "error", diag.error, "solution", diag.solution)
 
 	// Process in the main thread to avoid concurrent requests
 	// Use context with timeout for GitHub and AI API calls
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
+	ctx = logging.WithLogger(ctx, log)
+	ctx = logging.WithCorrelationID(ctx, correlationID)
 
 	// Extract the namespace from the Result resource
 	namespace := diag.namespace
-	fmt.Printf("Processing Result for namespace: %s\n", namespace)
+	log.Infow("processing result", "namespace", namespace)
 
 	// Fetch files from GitHub
 	files, err := fetchGitHubFiles(ctx, namespace)
 	if err != nil {
-		fmt.Printf("Error fetching GitHub files: %v\n", err)
+		log.Errorw("failed to fetch GitHub files", "error", err)
 		return
 	}
 
 	// If no files were found, log and return
 	if len(files) == 0 {
-		fmt.Printf("No files found in GitHub repository for namespace %s\n", namespace)
+		log.Infow("no files found in GitHub repository", "namespace", namespace)
 		return
 	}
 
 	// Send to AI endpoint
-	fileUpdates, err := sendToAIEndpoint(ctx, files, diag.solution)
+	aiResult, err := sendToAIEndpoint(ctx, files, diag)
 	if err != nil {
-		fmt.Printf("Error sending to AI endpoint: %v\n", err)
+		log.Errorw("failed to send to AI endpoint", "error", err)
 		return
 	}
+	fileUpdates := aiResult.FileUpdates
 
-	// Print the file updates that were returned
-	fmt.Printf("Received %d file updates from analysis\n", len(fileUpdates))
+	// Log the file updates that were returned
+	log.Infow("received file updates from analysis", "count", len(fileUpdates))
 	for _, update := range fileUpdates {
-		fmt.Printf("- File: %s (%d bytes)\n", update.Path, len(update.Content))
+		log.Debugw("proposed file update", "path", update.Path, "bytes", len(update.Content))
 	}
 
-	// Send updates to gh-service to create a PR
+	// Deliver the file updates per applyMode: "pr" opens a pull request
+	// through gh-service (or emits GitHub Actions workflow commands, if
+	// that's where we're running), "ssa" applies them directly to the live
+	// cluster via server-side apply, and "both" does both.
+	sent := false
+	var prUrl string
 	if len(fileUpdates) > 0 {
-		err = sendToGHService(ctx, fileUpdates, diag)
-		if err != nil {
-			fmt.Printf("Error sending to gh-service: %v\n", err)
-		} else {
-			fmt.Printf("Successfully sent updates to gh-service for PR creation\n")
+		if applyMode == "pr" || applyMode == "both" {
+			if isGitHubActionsEnvironment() {
+				if err := sendToGitHubActions(ctx, fileUpdates, diag); err != nil {
+					log.Errorw("failed to emit GitHub Actions output", "error", err)
+				} else {
+					sent = true
+				}
+			} else {
+				prUrl, err = sendToGHServiceWithRetry(ctx, diag, fileUpdates, files, func(path, content string) (string, error) {
+					result, err := sendToAIEndpoint(ctx, map[string]string{path: content}, diag)
+					if err != nil {
+						return "", err
+					}
+					for _, u := range result.FileUpdates {
+						if u.Path == path {
+							return u.Content, nil
+						}
+					}
+					return content, nil // AI/fallback proposed no change to this file; keep its current content
+				})
+				if err != nil {
+					log.Errorw("failed to send to gh-service", "error", err)
+				} else {
+					log.Infow("successfully sent updates to gh-service for PR creation", "pr_url", prUrl)
+					sent = true
+				}
+			}
+		}
+
+		if applyMode == "ssa" || applyMode == "both" {
+			if resourceApplier == nil {
+				log.Warnw("apply mode requires server-side apply but no resource applier is configured")
+			} else {
+				applied, err := resourceApplier.Apply(ctx, diag.namespace, toApplierFileUpdates(fileUpdates))
+				if err != nil {
+					log.Errorw("failed to apply file updates via server-side apply", "error", err, "applied_count", len(applied))
+				} else {
+					log.Infow("applied file updates via server-side apply", "count", len(applied))
+					sent = true
+				}
+				if len(applied) > 0 {
+					if err := recordAppliedRevision(ctx, resultNamespace, name, applied); err != nil {
+						log.Warnw("failed to record applied revision on Result", "error", err)
+					}
+				}
+			}
 		}
 	} else {
-		fmt.Printf("No file updates to send to gh-service\n")
+		log.Infow("no file updates to deliver")
 	}
-}
 
-// GitHub API response types
-type GitHubContent struct {
-	Type        string `json:"type"`
-	Name        string `json:"name"`
-	Path        string `json:"path"`
-	SHA         string `json:"sha"`
-	Size        int    `json:"size"`
-	URL         string `json:"url"`
-	HTMLURL     string `json:"html_url"`
-	GitURL      string `json:"git_url"`
-	DownloadURL string `json:"download_url"`
-	Content     string `json:"content"`
-	Encoding    string `json:"encoding"`
+	recordAudit(ctx, diag, name, aiResult, prUrl)
+
+	if sent && dedupeStore != nil {
+		if err := dedupeStore.Mark(resourceID, resourceVersion, dedupeTTL); err != nil {
+			log.Warnw("failed to mark resource as processed", "error", err)
+		}
+	}
 }
 
-// fetchGitHubFiles retrieves files from the GitHub repository for a specific namespace
-func fetchGitHubFiles(ctx context.Context, namespace string) (map[string]string, error) {
-	// Path in the repo to look for files - only check apps/<namespace>
-	dirPath := fmt.Sprintf("apps/%s", namespace)
-	fmt.Printf("Looking for files in GitHub path: %s\n", dirPath)
+// recordAudit persists the AI round-trip (or fallback) for diag, reported by
+// the Result named resultName, to the configured audit sink, logging a
+// warning on failure rather than derailing the rest of handleResult.
+func recordAudit(ctx context.Context, diag diagnostic, resultName string, result aiExchange, prUrl string) {
+	log := logging.FromContext(ctx)
 
-	// First, get the directory contents to find all files
-	files, err := fetchDirectoryContents(ctx, dirPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch files from %s: %w", dirPath, err)
-	}
+	endpointHealthMutex.Lock()
+	healthy := endpointHealthy
+	endpointHealthMutex.Unlock()
 
-	// Log success if we found files
-	if len(files) > 0 {
-		fmt.Printf("Successfully found %d files in %s\n", len(files), dirPath)
-	} else {
-		fmt.Printf("No files found in %s\n", dirPath)
+	envelope := audit.Envelope{
+		Diagnostic: audit.Diagnostic{
+			Name:      diag.name,
+			Kind:      diag.kind,
+			Namespace: diag.namespace,
+			Error:     diag.error,
+			Solution:  diag.solution,
+		},
+		CorrelationID:   logging.CorrelationID(ctx),
+		Prompt:          result.Prompt,
+		AIResponse:      result.RawResponse,
+		UsedFallback:    result.UsedFallback,
+		EndpointHealthy: healthy,
+		PRUrl:           prUrl,
+		Timestamp:       time.Now(),
+	}
+	for _, update := range result.FileUpdates {
+		envelope.FileUpdates = append(envelope.FileUpdates, audit.FileUpdate{Path: update.Path, Content: update.Content})
 	}
 
-	return files, nil
+	if err := auditSink.Record(ctx, diag.namespace, resultName, envelope); err != nil {
+		log.Warnw("failed to record audit envelope", "error", err)
+	}
 }
 
-// fetchDirectoryContents uses GitHub API to get contents of a directory
-func fetchDirectoryContents(ctx context.Context, dirPath string) (map[string]string, error) {
-	files := make(map[string]string)
+// toApplierFileUpdates converts the controller's FileUpdate into the
+// applier package's dependency-free type.
+func toApplierFileUpdates(updates []FileUpdate) []applier.FileUpdate {
+	out := make([]applier.FileUpdate, len(updates))
+	for i, u := range updates {
+		out[i] = applier.FileUpdate{Path: u.Path, Content: u.Content}
+	}
+	return out
+}
 
-	// First get the directory listing
-	contentsURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
-		githubApiUrl, githubOwner, githubRepo, dirPath, githubBranch)
+// appliedRevisionAnnotation is set on the triggering Result's metadata (not
+// its status subresource: annotations live in metadata, not status) after a
+// successful server-side apply, recording what was applied so a later
+// reconcile of the same Result can detect drift and decide whether to re-sync.
+const appliedRevisionAnnotation = "core.k8sgpt.ai/prof-tournesol-applied-revision"
 
-	fmt.Printf("Fetching directory contents from: %s\n", contentsURL)
+type appliedRevision struct {
+	Objects   []applier.AppliedObject `json:"objects"`
+	AppliedAt time.Time               `json:"applied_at"`
+}
 
-	// Create request with GitHub API token if available
-	req, err := http.NewRequestWithContext(ctx, "GET", contentsURL, nil)
+// recordAppliedRevision merge-patches resultName's appliedRevisionAnnotation
+// with the objects just applied via server-side apply.
+func recordAppliedRevision(ctx context.Context, resultNamespace, resultName string, applied []applier.AppliedObject) error {
+	data, err := json.Marshal(appliedRevision{Objects: applied, AppliedAt: time.Now()})
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return fmt.Errorf("failed to marshal applied revision: %w", err)
 	}
 
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	if githubToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", githubToken))
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				appliedRevisionAnnotation: string(data),
+			},
+		},
 	}
-
-	// Send the request
-	resp, err := httpClient.Do(req)
+	patchBytes, err := json.Marshal(patch)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching directory contents: %w", err)
+		return fmt.Errorf("failed to marshal annotation patch: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Check for rate limiting
-	if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
-		resetTime := resp.Header.Get("X-RateLimit-Reset")
-		return nil, fmt.Errorf("GitHub API rate limit exceeded. Rate limit resets at %s", resetTime)
+	_, err = dynamicClient.Resource(resultGVR).Namespace(resultNamespace).Patch(
+		ctx, resultName, types.MergePatchType, patchBytes, metav1.PatchOptions{FieldManager: applier.FieldManager},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to patch Result %s/%s: %w", resultNamespace, resultName, err)
 	}
+	return nil
+}
 
-	// Handle 404 - directory doesn't exist
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("directory not found: %s", dirPath)
-	}
+// GitHub Git Data API response types, used by the trees+blobs fetch path.
+type GitHubCommit struct {
+	SHA string `json:"sha"`
+}
 
-	// Handle other errors
-	if resp.StatusCode >= 400 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(bodyBytes))
-	}
+type GitTreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"` // "blob" or "tree"
+	SHA  string `json:"sha"`
+	Size int    `json:"size"`
+}
 
-	// Parse the response
-	var contents []GitHubContent
-	if err := json.NewDecoder(resp.Body).Decode(&contents); err != nil {
-		// Try to decode as a single file instead of a directory
-		resp.Body.Close()
+type GitTree struct {
+	SHA       string         `json:"sha"`
+	Tree      []GitTreeEntry `json:"tree"`
+	Truncated bool           `json:"truncated"`
+}
+
+type GitBlob struct {
+	SHA      string `json:"sha"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+	Size     int    `json:"size"`
+}
 
-		// If it's a file, fetch it directly
-		return fetchSingleFile(ctx, dirPath)
+// getBlobCache lazily initializes the on-disk blob cache on first use. If
+// initialization fails (e.g. an unwritable cache directory), caching is
+// simply skipped and every blob is fetched from the API.
+func getBlobCache() *filecache.Cache {
+	blobCacheOnce.Do(func() {
+		cache, err := filecache.New(time.Duration(fileCacheTTLSecs)*time.Second, int64(fileCacheMaxSizeMB)*1024*1024)
+		if err != nil {
+			logger.Warnw("failed to initialize file cache, continuing without it", "error", err)
+			return
+		}
+		blobCache = cache
+	})
+	return blobCache
+}
+
+// newDedupeStore builds the dedupe.Store selected by DEDUPE_BACKEND. "file"
+// (the default) keeps a JSON file on the controller's local disk, which is
+// enough for a single-replica deployment; "configmap" shares state across
+// replicas via the Kubernetes API using the same dynamic client as the Result
+// informer.
+func newDedupeStore(client dynamic.Interface) (dedupe.Store, error) {
+	switch dedupeBackend {
+	case "configmap":
+		return dedupe.NewConfigMapStore(client, dedupeNamespace, dedupeConfigMapName), nil
+	case "file":
+		return dedupe.NewFileStore(dedupeFilePath)
+	default:
+		return nil, fmt.Errorf("unknown DEDUPE_BACKEND %q (expected \"file\" or \"configmap\")", dedupeBackend)
 	}
+}
 
-	// Process each item in the directory
-	for _, item := range contents {
-		// Skip directories, only process files
-		if item.Type == "dir" {
-			subDirFiles, err := fetchDirectoryContents(ctx, item.Path)
-			if err != nil {
-				fmt.Printf("Warning: Failed to fetch subdirectory %s: %v\n", item.Path, err)
-				continue
-			}
+// newAuditSink builds the audit.Sink selected by AUDIT_BACKEND. "none" (the
+// default) discards every record; "file" is for local development; "s3"
+// writes to an S3/MinIO bucket for postmortem review and prompt refinement.
+func newAuditSink(ctx context.Context) (audit.Sink, error) {
+	switch auditBackend {
+	case "s3":
+		return audit.NewS3Sink(ctx, audit.S3Config{
+			Endpoint:  auditEndpoint,
+			Bucket:    auditBucket,
+			AccessKey: auditAccessKey,
+			SecretKey: auditSecretKey,
+			UseSSL:    auditUseSSL,
+		})
+	case "file":
+		return audit.NewFileSink(auditDir)
+	case "none":
+		return audit.NoopSink{}, nil
+	default:
+		return nil, fmt.Errorf("unknown AUDIT_BACKEND %q (expected \"none\", \"file\", or \"s3\")", auditBackend)
+	}
+}
 
-			// Add subdirectory files to main files map
-			for name, content := range subDirFiles {
-				// Create path relative to the original directory
-				relativePath := filepath.Join(strings.TrimPrefix(item.Path, dirPath+"/"), name)
-				files[relativePath] = content
+// runDedupeCompaction periodically removes expired entries from store until
+// stopCh is closed, so long-running controllers don't accumulate stale marks
+// forever.
+func runDedupeCompaction(store dedupe.Compactable, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(dedupeCompactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := store.Compact(); err != nil {
+				logger.Warnw("dedupe store compaction failed", "error", err)
 			}
-			continue
+		case <-stopCh:
+			return
 		}
+	}
+}
 
-		// For files, fetch the content
-		fileContent, err := fetchFileContent(ctx, item.Path)
-		if err != nil {
-			fmt.Printf("Warning: Failed to fetch file %s: %v\n", item.Path, err)
-			continue
-		}
+// fetchGitHubFiles retrieves files from the GitHub repository for a specific
+// namespace by resolving the configured branch to a commit, fetching the
+// full recursive tree once, and fetching only the blobs under apps/<namespace>.
+func fetchGitHubFiles(ctx context.Context, namespace string) (map[string]string, error) {
+	log := logging.FromContext(ctx)
+
+	dirPath := fmt.Sprintf("apps/%s", namespace)
+	log.Debugw("looking for files in GitHub path", "path", dirPath)
+
+	commitSHA, err := resolveCommitSHA(ctx, githubBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve branch %s to a commit: %w", githubBranch, err)
+	}
 
-		// Add to files map with path relative to the requested directory
-		relativePath := strings.TrimPrefix(item.Path, dirPath+"/")
-		if relativePath == "" {
-			relativePath = item.Name
+	tree, err := fetchGitTree(ctx, commitSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch git tree for commit %s: %w", commitSHA, err)
+	}
+	if tree.Truncated {
+		log.Warnw("tree response was truncated by the GitHub API", "commit_sha", commitSHA)
+	}
+
+	var blobEntries []GitTreeEntry
+	prefix := dirPath + "/"
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" && strings.HasPrefix(entry.Path, prefix) {
+			blobEntries = append(blobEntries, entry)
 		}
+	}
 
-		files[relativePath] = fileContent
+	if len(blobEntries) == 0 {
+		log.Infow("no files found", "path", dirPath)
+		return map[string]string{}, nil
 	}
 
-	// Check if we found any files
-	if len(files) == 0 {
-		return nil, fmt.Errorf("no files found at %s", dirPath)
+	files, err := fetchBlobsConcurrently(ctx, blobEntries, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch files from %s: %w", dirPath, err)
 	}
 
+	log.Infow("successfully fetched files", "count", len(files), "path", dirPath)
 	return files, nil
 }
 
-// fetchSingleFile fetches a single file content if the path is a file, not a directory
-func fetchSingleFile(ctx context.Context, filePath string) (map[string]string, error) {
-	files := make(map[string]string)
+// resolveCommitSHA resolves a branch, tag, or other ref to the SHA of the
+// commit it currently points to.
+func resolveCommitSHA(ctx context.Context, ref string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", githubApiUrl, githubOwner, githubRepo, ref)
 
-	// Get file content URL
-	contentURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
-		githubApiUrl, githubOwner, githubRepo, filePath, githubBranch)
+	var commit GitHubCommit
+	if err := getGitHubJSON(ctx, url, &commit); err != nil {
+		return "", err
+	}
+	if commit.SHA == "" {
+		return "", fmt.Errorf("no commit sha returned for ref %s", ref)
+	}
 
-	fmt.Printf("Fetching file content from: %s\n", contentURL)
+	return commit.SHA, nil
+}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", contentURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+// fetchGitTree fetches the full recursive tree for a commit.
+func fetchGitTree(ctx context.Context, commitSHA string) (*GitTree, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1", githubApiUrl, githubOwner, githubRepo, commitSHA)
+
+	var tree GitTree
+	if err := getGitHubJSON(ctx, url, &tree); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	if githubToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", githubToken))
+	return &tree, nil
+}
+
+// fetchBlobsConcurrently fetches each tree entry's blob content through a
+// bounded worker pool, consulting the on-disk blob cache first. Returned
+// paths are relative to prefix, matching the map[string]string contract
+// consumed by the AI prompt builder.
+func fetchBlobsConcurrently(ctx context.Context, entries []GitTreeEntry, prefix string) (map[string]string, error) {
+	type result struct {
+		relPath string
+		content string
+		err     error
+	}
+
+	jobs := make(chan GitTreeEntry)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < fileCacheWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				content, err := fetchBlob(ctx, entry.SHA)
+				relPath := strings.TrimPrefix(entry.Path, prefix)
+				results <- result{relPath: relPath, content: content, err: err}
+			}
+		}()
 	}
 
-	// Send the request
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching file content: %w", err)
+	go func() {
+		for _, entry := range entries {
+			jobs <- entry
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	log := logging.FromContext(ctx)
+
+	files := make(map[string]string, len(entries))
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			log.Warnw("failed to fetch blob", "path", r.relPath, "error", r.err)
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		files[r.relPath] = r.content
 	}
-	defer resp.Body.Close()
 
-	// Handle errors
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	if len(files) == 0 && firstErr != nil {
+		return nil, firstErr
 	}
 
-	// Parse the response
-	var content GitHubContent
-	if err := json.NewDecoder(resp.Body).Decode(&content); err != nil {
-		return nil, fmt.Errorf("error decoding file content: %w", err)
+	if cache := getBlobCache(); cache != nil {
+		go func() {
+			if err := cache.Compact(); err != nil {
+				log.Warnw("blob cache compaction failed", "error", err)
+			}
+		}()
 	}
 
-	// Decode base64 content
-	if content.Content != "" && content.Encoding == "base64" {
-		decodedContent, err := base64.StdEncoding.DecodeString(
-			strings.ReplaceAll(content.Content, "\n", ""))
-		if err != nil {
-			return nil, fmt.Errorf("error decoding base64 content: %w", err)
+	return files, nil
+}
+
+// fetchBlob returns the decoded content of a single blob, using the on-disk
+// cache when available since a blob SHA is a content hash and never goes stale.
+func fetchBlob(ctx context.Context, sha string) (string, error) {
+	if cache := getBlobCache(); cache != nil {
+		if data, ok := cache.Get(sha); ok {
+			return string(data), nil
 		}
+	}
 
-		files[filepath.Base(filePath)] = string(decodedContent)
-		return files, nil
+	url := fmt.Sprintf("%s/repos/%s/%s/git/blobs/%s", githubApiUrl, githubOwner, githubRepo, sha)
+
+	var blob GitBlob
+	if err := getGitHubJSON(ctx, url, &blob); err != nil {
+		return "", err
+	}
+	if blob.Encoding != "base64" {
+		return "", fmt.Errorf("unexpected blob encoding %q for sha %s", blob.Encoding, sha)
 	}
 
-	return nil, errors.New("file content not available")
-}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.Content, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("error decoding base64 content for blob %s: %w", sha, err)
+	}
 
-// fetchFileContent fetches the content of a single file
-func fetchFileContent(ctx context.Context, filePath string) (string, error) {
-	// Get file content URL
-	contentURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
-		githubApiUrl, githubOwner, githubRepo, filePath, githubBranch)
+	if cache := getBlobCache(); cache != nil {
+		if err := cache.Put(sha, decoded); err != nil {
+			logging.FromContext(ctx).Warnw("failed to cache blob", "sha", sha, "error", err)
+		}
+	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", contentURL, nil)
+	return string(decoded), nil
+}
+
+// getGitHubJSON performs an authenticated GET against the GitHub API and
+// decodes the JSON response into out, with the same rate-limit handling used
+// throughout this file.
+func getGitHubJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+		return fmt.Errorf("error creating request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
@@ -412,78 +726,48 @@ func fetchFileContent(ctx context.Context, filePath string) (string, error) {
 		req.Header.Set("Authorization", fmt.Sprintf("token %s", githubToken))
 	}
 
-	// Implement retry with backoff for rate limiting
-	var resp *http.Response
-	maxRetries := 3
-
-	for i := 0; i < maxRetries; i++ {
-		// Send the request
-		resp, err = httpClient.Do(req)
-		if err != nil {
-			return "", fmt.Errorf("error fetching file content: %w", err)
-		}
-
-		// If rate limited, wait and retry
-		if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
-			resp.Body.Close()
-
-			// Parse the reset time and wait
-			resetTimeStr := resp.Header.Get("X-RateLimit-Reset")
-			if resetTimeStr != "" {
-				resetTime, parseErr := strconv.ParseInt(resetTimeStr, 10, 64)
-				if parseErr == nil {
-					waitTime := time.Until(time.Unix(resetTime, 0))
-					if waitTime > 0 && waitTime < 5*time.Minute {
-						fmt.Printf("Rate limited. Waiting %s before retry\n", waitTime)
-						time.Sleep(waitTime + time.Second)
-						continue
-					}
-				}
-			}
-
-			// If we can't parse the reset time, use exponential backoff
-			waitTime := time.Duration(1<<uint(i)) * time.Second
-			fmt.Printf("Rate limited. Using exponential backoff: waiting %s before retry\n", waitTime)
-			time.Sleep(waitTime)
-			continue
-		}
-
-		// Break the loop if we got a response
-		break
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling GitHub API: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Handle errors
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		resetTime := resp.Header.Get("X-RateLimit-Reset")
+		return fmt.Errorf("GitHub API rate limit exceeded. Rate limit resets at %s", resetTime)
 	}
 
-	// Parse the response
-	var content GitHubContent
-	if err := json.NewDecoder(resp.Body).Decode(&content); err != nil {
-		return "", fmt.Errorf("error decoding file content: %w", err)
+	if resp.StatusCode >= 400 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	// Decode base64 content
-	if content.Content != "" && content.Encoding == "base64" {
-		decodedContent, err := base64.StdEncoding.DecodeString(
-			strings.ReplaceAll(content.Content, "\n", ""))
-		if err != nil {
-			return "", fmt.Errorf("error decoding base64 content: %w", err)
-		}
-
-		return string(decodedContent), nil
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding GitHub API response: %w", err)
 	}
 
-	return "", errors.New("file content not available")
+	return nil
+}
+
+// aiExchange carries everything about a sendToAIEndpoint call worth auditing:
+// the prompt that was sent, the raw assistant response (empty if the local
+// fallback was used instead), and whether that fallback was used.
+type aiExchange struct {
+	FileUpdates  []FileUpdate
+	Prompt       string
+	RawResponse  string
+	UsedFallback bool
 }
 
-// sendToAIEndpoint sends the files and solution to the AI endpoint and returns file updates
-func sendToAIEndpoint(ctx context.Context, files map[string]string, solution string) ([]FileUpdate, error) {
-	fmt.Printf("Preparing to send data to AI endpoint: %s\n", aiEndpointUrl)
-	fmt.Printf("Using model: %s with timeout %d seconds and max %d retries\n",
-		aiModel, aiTimeoutSecs, aiMaxRetries)
+// sendToAIEndpoint sends the files and diagnostic to the AI endpoint and
+// returns file updates. If the AI endpoint is unhealthy or exhausts its
+// retries, it falls back to the remediation strategy registry.
+func sendToAIEndpoint(ctx context.Context, files map[string]string, diag diagnostic) (aiExchange, error) {
+	log := logging.FromContext(ctx)
+	log.Infow("preparing to send data to AI endpoint", "url", aiEndpointUrl,
+		"model", aiModel, "timeout_seconds", aiTimeoutSecs, "max_retries", aiMaxRetries)
+
+	solution := diag.solution
 
 	// Format content for AI processing with explicit instructions for the output format
 	var content strings.Builder
@@ -491,7 +775,7 @@ func sendToAIEndpoint(ctx context.Context, files map[string]string, solution str
 	content.WriteString("# Related Files\n\n")
 
 	for filename, fileContent := range files {
-		fmt.Printf("Including file in analysis: %s (%d bytes)\n", filename, len(fileContent))
+		log.Debugw("including file in analysis", "path", filename, "bytes", len(fileContent))
 		content.WriteString(fmt.Sprintf("## %s\n```yaml\n%s\n```\n\n", filename, fileContent))
 	}
 
@@ -513,13 +797,15 @@ If the error is in the application code itself or cannot be fixed by editing YAM
 respond with an empty array: []
 `)
 
+	prompt := content.String()
+
 	// Create payload for the AI endpoint
 	payload := map[string]interface{}{
 		"model": aiModel,
 		"messages": []map[string]interface{}{
 			{
 				"role":    "user",
-				"content": content.String(),
+				"content": prompt,
 			},
 			{
 				"role":    "system",
@@ -527,27 +813,33 @@ respond with an empty array: []
 			},
 		},
 		"temperature": 0.2, // Lower temperature for more deterministic responses
-		"stream":      false,
+		"stream":      aiStream,
 		"max_tokens":  4096,
 	}
 
 	// Marshal to JSON
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal JSON payload: %w", err)
+		return aiExchange{}, fmt.Errorf("failed to marshal JSON payload: %w", err)
+	}
+
+	fallback := func() aiExchange {
+		return aiExchange{FileUpdates: generateLocalFallbackResponse(ctx, diag, files), Prompt: prompt, UsedFallback: true}
 	}
 
 	// Check endpoint health if enabled
 	if aiHealthCheck {
 		healthy := checkAIEndpointHealth(ctx)
 		if !healthy {
-			fmt.Printf("‚ö†Ô∏è AI endpoint is not healthy, using local fallback response\n")
-			return generateLocalFallbackResponse(solution, files), nil
+			log.Warnw("AI endpoint is not healthy, using local fallback response")
+			return fallback(), nil
 		}
 	}
 
 	// Implement retry with exponential backoff
-	var responseData map[string]interface{}
+	var fileUpdates []FileUpdate
+	var rawResponse string
+	var gotResponse bool
 
 	for attempt := 0; attempt < aiMaxRetries; attempt++ {
 		if attempt > 0 {
@@ -557,8 +849,7 @@ respond with an empty array: []
 				backoffTime = 30 * time.Second
 			}
 
-			fmt.Printf("Retry attempt %d/%d after waiting %v\n",
-				attempt+1, aiMaxRetries, backoffTime)
+			log.Infow("retrying AI endpoint after backoff", "attempt", attempt+1, "max_retries", aiMaxRetries, "backoff", backoffTime)
 			time.Sleep(backoffTime)
 		}
 
@@ -566,16 +857,27 @@ respond with an empty array: []
 		attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(aiTimeoutSecs)*time.Second)
 		defer cancel()
 
-		// Try to call the AI endpoint
-		resp, err := tryAIEndpoint(attemptCtx, jsonData)
+		// Try to call the AI endpoint, streaming the response when AI_STREAM is enabled
+		var err error
+		if aiStream {
+			fileUpdates, rawResponse, err = tryAIEndpointStream(attemptCtx, jsonData)
+		} else {
+			var resp map[string]interface{}
+			resp, err = tryAIEndpoint(attemptCtx, jsonData)
+			if err == nil {
+				rawResponse = extractMessageContent(resp)
+				fileUpdates, err = extractFileUpdatesFromResponse(resp)
+			}
+		}
+
 		if err == nil {
 			// Update endpoint health state on success
 			updateEndpointHealth(true)
-			responseData = resp
+			gotResponse = true
 			break // Success!
 		}
 
-		fmt.Printf("Attempt %d/%d failed: %v\n", attempt+1, aiMaxRetries, err)
+		log.Warnw("AI endpoint attempt failed", "attempt", attempt+1, "max_retries", aiMaxRetries, "error", err)
 
 		// Don't retry on certain errors
 		if strings.Contains(err.Error(), "received non-success status code") &&
@@ -584,27 +886,18 @@ respond with an empty array: []
 			!strings.Contains(err.Error(), "502") && // Retry on 502 (Bad Gateway)
 			!strings.Contains(err.Error(), "503") && // Retry on 503 (Service Unavailable)
 			!strings.Contains(err.Error(), "504") { // Retry on 504 (Gateway Timeout)
-			fmt.Printf("Not retrying due to non-retryable error\n")
-
-			// Use fallback response after exhausting retries for this attempt
-			fmt.Printf("‚ö†Ô∏è Using local fallback response after non-retryable error\n")
-			return generateLocalFallbackResponse(solution, files), nil
+			log.Warnw("not retrying due to non-retryable error, using local fallback response")
+			return fallback(), nil
 		}
 	}
 
-	// If we got a response, try to extract the file updates
-	if responseData != nil {
-		fileUpdates, err := extractFileUpdatesFromResponse(responseData)
-		if err != nil {
-			fmt.Printf("Error extracting file updates from response: %v\n", err)
-			return generateLocalFallbackResponse(solution, files), nil
-		}
-		return fileUpdates, nil
+	if gotResponse {
+		return aiExchange{FileUpdates: fileUpdates, Prompt: prompt, RawResponse: rawResponse}, nil
 	}
 
 	// All attempts failed, use fallback response
-	fmt.Printf("‚ö†Ô∏è All %d attempts failed, using local fallback response\n", aiMaxRetries)
-	return generateLocalFallbackResponse(solution, files), nil
+	log.Warnw("all AI endpoint attempts failed, using local fallback response", "max_retries", aiMaxRetries)
+	return fallback(), nil
 }
 
 // extractFileUpdatesFromResponse extracts file updates from AI response
@@ -655,14 +948,42 @@ func extractFileUpdatesFromResponse(responseData map[string]interface{}) ([]File
 	return fileUpdates, nil
 }
 
+// extractMessageContent pulls choices[0].message.content out of a complete
+// (non-streaming) chat completion response, for audit purposes. Returns ""
+// if the response doesn't have the expected shape.
+func extractMessageContent(responseData map[string]interface{}) string {
+	choices, ok := responseData["choices"].([]interface{})
+	if !ok || len(choices) == 0 {
+		return ""
+	}
+
+	choice, ok := choices[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	message, ok := choice["message"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	content, _ := message["content"].(string)
+	return content
+}
+
 // tryAIEndpoint attempts to call the AI endpoint once
 func tryAIEndpoint(ctx context.Context, jsonData []byte) (map[string]interface{}, error) {
+	log := logging.FromContext(ctx)
+
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "POST", aiEndpointUrl, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if correlationID := logging.CorrelationID(ctx); correlationID != "" {
+		req.Header.Set("X-Correlation-ID", correlationID)
+	}
 
 	// Skip connection test if we already know the endpoint is healthy
 	endpointHealthMutex.Lock()
@@ -675,18 +996,18 @@ func tryAIEndpoint(ctx context.Context, jsonData []byte) (map[string]interface{}
 		defer cancel()
 
 		testReq, _ := http.NewRequestWithContext(testCtx, "HEAD", aiBaseUrl, nil)
-		fmt.Printf("Testing connectivity to AI base URL: %s\n", aiBaseUrl)
+		log.Debugw("testing connectivity to AI base URL", "url", aiBaseUrl)
 		testResp, testErr := httpClient.Do(testReq)
 		if testErr != nil {
-			fmt.Printf("‚ö†Ô∏è Warning: Connectivity test failed: %v\n", testErr)
+			log.Warnw("AI base URL connectivity test failed", "error", testErr)
 		} else {
 			testResp.Body.Close()
-			fmt.Printf("‚úÖ Base URL connectivity test successful (status: %s)\n", testResp.Status)
+			log.Debugw("AI base URL connectivity test successful", "status", testResp.Status)
 		}
 	}
 
 	// Send the actual request
-	fmt.Printf("Sending request to AI endpoint: %s\n", aiEndpointUrl)
+	log.Debugw("sending request to AI endpoint", "url", aiEndpointUrl)
 	startTime := time.Now()
 	resp, err := httpClient.Do(req)
 	requestDuration := time.Since(startTime)
@@ -704,9 +1025,8 @@ func tryAIEndpoint(ctx context.Context, jsonData []byte) (map[string]interface{}
 	defer resp.Body.Close()
 
 	// Log response headers for debugging
-	fmt.Printf("Response received in %v - status: %s\n", requestDuration, resp.Status)
-	fmt.Printf("Response headers: Content-Type=%s, Content-Length=%s\n",
-		resp.Header.Get("Content-Type"), resp.Header.Get("Content-Length"))
+	log.Debugw("response received", "duration", requestDuration, "status", resp.Status,
+		"content_type", resp.Header.Get("Content-Type"), "content_length", resp.Header.Get("Content-Length"))
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -721,7 +1041,7 @@ func tryAIEndpoint(ctx context.Context, jsonData []byte) (map[string]interface{}
 	}
 
 	// Log successful response
-	fmt.Printf("Successfully received response from AI model %s\n", aiModel)
+	log.Infow("successfully received response from AI model", "model", aiModel)
 
 	// Extract and log a sample of the response content for debugging
 	if choices, ok := responseData["choices"].([]interface{}); ok && len(choices) > 0 {
@@ -732,9 +1052,9 @@ func tryAIEndpoint(ctx context.Context, jsonData []byte) (map[string]interface{}
 					if content, ok := message["content"].(string); ok {
 						previewLen := 200
 						if len(content) > previewLen {
-							fmt.Printf("AI Response Preview: %s...\n", content[:previewLen])
+							log.Debugw("AI response preview", "content", content[:previewLen]+"...")
 						} else {
-							fmt.Printf("AI Response: %s\n", content)
+							log.Debugw("AI response", "content", content)
 						}
 					}
 				}
@@ -745,9 +1065,180 @@ func tryAIEndpoint(ctx context.Context, jsonData []byte) (map[string]interface{}
 	return responseData, nil
 }
 
+// tryAIEndpointStream sends the chat completion request with stream:true and
+// incrementally parses the text/event-stream response, returning as soon as
+// the accumulated delta content contains a complete, balanced top-level JSON
+// array of FileUpdate objects. This avoids buffering the whole reply, which
+// matters for small local models like gemma3-1b-cpu on large repos.
+func tryAIEndpointStream(ctx context.Context, jsonData []byte) ([]FileUpdate, string, error) {
+	log := logging.FromContext(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", aiEndpointUrl, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if correlationID := logging.CorrelationID(ctx); correlationID != "" {
+		req.Header.Set("X-Correlation-ID", correlationID)
+	}
+
+	log.Debugw("sending streaming request to AI endpoint", "url", aiEndpointUrl)
+	startTime := time.Now()
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		updateEndpointHealth(false)
+		if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "timeout") ||
+			strings.Contains(err.Error(), "deadline") {
+			return nil, "", fmt.Errorf("request timed out after %v: %w", time.Since(startTime), err)
+		}
+		return nil, "", fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, "", fmt.Errorf("received non-success status code %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var buffer strings.Builder
+	bytesReceived := 0
+	lastHeartbeat := time.Now()
+	searchFrom := 0
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		bytesReceived += len(line)
+
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "data:") {
+			data := strings.TrimSpace(strings.TrimPrefix(trimmed, "data:"))
+			if data == "[DONE]" {
+				break
+			}
+
+			var chunk map[string]interface{}
+			if jsonErr := json.Unmarshal([]byte(data), &chunk); jsonErr == nil {
+				if delta := extractDeltaContent(chunk); delta != "" {
+					buffer.WriteString(delta)
+
+					if arr, start, ok := extractBalancedJSONArray(buffer.String(), searchFrom); ok {
+						var fileUpdates []FileUpdate
+						if parseErr := json.Unmarshal([]byte(arr), &fileUpdates); parseErr == nil {
+							updateEndpointHealth(true)
+							log.Debugw("parsed complete file update array", "bytes", bytesReceived, "elapsed", time.Since(startTime))
+							return fileUpdates, buffer.String(), nil
+						}
+						// Balanced but didn't unmarshal into []FileUpdate - either
+						// stray bracketed chatter before the real array (e.g. "item
+						// [1]:") or a stray bracket in file content. Either way this
+						// candidate start can never re-balance differently as more
+						// text arrives, so advance past it and look for the next
+						// '[' instead of re-finding the same bad candidate forever.
+						searchFrom = start + 1
+					}
+				}
+			}
+		}
+
+		if time.Since(lastHeartbeat) > 5*time.Second {
+			log.Debugw("streaming AI response", "bytes_received", bytesReceived, "elapsed", time.Since(startTime))
+			lastHeartbeat = time.Now()
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			}
+			return nil, buffer.String(), fmt.Errorf("error reading stream after %d bytes: %w", bytesReceived, readErr)
+		}
+	}
+
+	updateEndpointHealth(true)
+	return nil, buffer.String(), fmt.Errorf("stream ended without a complete file update array (%d bytes received)", bytesReceived)
+}
+
+// extractDeltaContent pulls choices[0].delta.content out of a single parsed
+// streaming chunk, returning "" if the chunk carries no content delta.
+func extractDeltaContent(chunk map[string]interface{}) string {
+	choices, ok := chunk["choices"].([]interface{})
+	if !ok || len(choices) == 0 {
+		return ""
+	}
+
+	choice, ok := choices[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	delta, ok := choice["delta"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	content, _ := delta["content"].(string)
+	return content
+}
+
+// extractBalancedJSONArray scans s, starting no earlier than from, for the
+// first top-level JSON array and, once its matching closing bracket has been
+// seen, returns its text and the offset in s where it started. Bracket depth
+// tracking respects string literals and escape sequences so brackets inside
+// quoted file content don't throw off the count.
+//
+// Callers that find a balanced-but-invalid candidate (e.g. chatter like
+// "item [1]:" preceding the real array) should retry with from set to
+// start+1: that candidate is already closed and will never re-balance into
+// something else as more of the stream arrives, so re-scanning from the same
+// '[' would just find the same wrong substring forever.
+func extractBalancedJSONArray(s string, from int) (arr string, start int, ok bool) {
+	start = strings.IndexByte(s[from:], '[')
+	if start < 0 {
+		return "", 0, false
+	}
+	start += from
+
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return s[start : i+1], start, true
+			}
+		}
+	}
+
+	return "", 0, false
+}
+
 // checkAIEndpointHealth checks if the AI endpoint is responsive
 // Returns true if healthy, false if not
 func checkAIEndpointHealth(ctx context.Context) bool {
+	log := logging.FromContext(ctx)
+
 	// Check if we've done a health check recently (within the last minute)
 	endpointHealthMutex.Lock()
 	defer endpointHealthMutex.Unlock()
@@ -764,17 +1255,17 @@ func checkAIEndpointHealth(ctx context.Context) bool {
 	healthCheckUrl := aiBaseUrl + "/models"
 	req, err := http.NewRequestWithContext(healthCheckCtx, "GET", healthCheckUrl, nil)
 	if err != nil {
-		fmt.Printf("‚ùå Health check failed to create request: %v\n", err)
+		log.Errorw("health check failed to create request", "error", err)
 		endpointHealthy = false
 		lastHealthCheck = time.Now()
 		return false
 	}
 
 	// Send the request
-	fmt.Printf("üîç Performing health check on %s\n", healthCheckUrl)
+	log.Debugw("performing health check", "url", healthCheckUrl)
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		fmt.Printf("‚ùå Health check failed: %v\n", err)
+		log.Warnw("health check failed", "error", err)
 		endpointHealthy = false
 		lastHealthCheck = time.Now()
 		return false
@@ -783,14 +1274,14 @@ func checkAIEndpointHealth(ctx context.Context) bool {
 
 	// Check if response is successful
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		fmt.Printf("‚úÖ AI endpoint health check passed (status: %s)\n", resp.Status)
+		log.Infow("AI endpoint health check passed", "status", resp.Status)
 		endpointHealthy = true
 		lastHealthCheck = time.Now()
 		return true
 	}
 
 	// Handle unsuccessful response
-	fmt.Printf("‚ùå AI endpoint health check failed with status: %s\n", resp.Status)
+	log.Warnw("AI endpoint health check failed", "status", resp.Status)
 	endpointHealthy = false
 	lastHealthCheck = time.Now()
 	return false
@@ -805,192 +1296,86 @@ func updateEndpointHealth(healthy bool) {
 	lastHealthCheck = time.Now()
 }
 
-// generateLocalFallbackResponse creates a local response when AI endpoint is unavailable
-// Returns an array of FileUpdate objects in the expected format
-func generateLocalFallbackResponse(solution string, files map[string]string) []FileUpdate {
-	fmt.Printf("\n=== LOCAL RESPONSE (AI UNAVAILABLE) ===\n")
-	fmt.Printf("Based on the provided information, generating fallback response\n")
-
-	// Initialize the result
-	var fileUpdates []FileUpdate
-
-	// Check if this is an OOM issue
-	isOOM := strings.Contains(strings.ToLower(solution), "oomkilled") ||
-		strings.Contains(strings.ToLower(solution), "out of memory")
-
-	if !isOOM {
-		fmt.Printf("Not an OOM issue, returning empty updates\n")
-		fmt.Printf("=== END LOCAL RESPONSE ===\n\n")
+// generateLocalFallbackResponse creates a local response when the AI endpoint
+// is unavailable, by dispatching diag through the remediation strategy
+// registry instead of talking to the AI. Returns an array of FileUpdate
+// objects in the expected format.
+func generateLocalFallbackResponse(ctx context.Context, diag diagnostic, files map[string]string) []FileUpdate {
+	log := logging.FromContext(ctx)
+	log.Infow("AI unavailable, generating local fallback response")
+
+	registry := getStrategyRegistry(ctx)
+	if registry == nil {
+		log.Warnw("no remediation strategy registry available, returning empty updates")
 		return []FileUpdate{}
 	}
 
-	// Try to find deployment files with memory limits
-	for name, content := range files {
-		fmt.Printf("Processing file: %s\n", name)
-
-		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
-			var deploymentName string
-			var isDeployment bool
-			var memoryLimit string
-
-			// Try to extract deployment name
-			if strings.Contains(content, "kind: Deployment") {
-				isDeployment = true
-				if idx := strings.Index(content, "name:"); idx >= 0 {
-					subContent := content[idx+5:]
-					endIdx := strings.Index(subContent, "\n")
-					if endIdx > 0 {
-						deploymentName = strings.TrimSpace(subContent[:endIdx])
-					}
-				}
-
-				// Extract namespace if available (not used currently)
-				if idx := strings.Index(content, "namespace:"); idx >= 0 {
-					// We could extract namespace here if needed
-					// Currently not used in our logic
-				}
-
-				// Check for memory limits
-				if strings.Contains(content, "resources") {
-					if idx := strings.Index(content, "limits:"); idx >= 0 {
-						subContent := content[idx:]
-						if memIdx := strings.Index(subContent, "memory:"); memIdx >= 0 {
-							endIdx := strings.Index(subContent[memIdx+7:], "\n")
-							if endIdx > 0 {
-								memoryLimit = strings.TrimSpace(subContent[memIdx+7 : memIdx+7+endIdx])
-							}
-						}
-					}
-				}
-
-				if isDeployment && deploymentName != "" {
-					// If we found a deployment, try to update its memory limit
-					updatedContent := content
-
-					// Parse the current memory limit
-					var currentMem int
-					var unit string
-
-					if memoryLimit != "" {
-						// Parse values like "6Mi", "256Mi", "1Gi"
-						numPart := ""
-						unitPart := ""
-						for i, c := range memoryLimit {
-							if c >= '0' && c <= '9' {
-								numPart += string(c)
-							} else {
-								unitPart = memoryLimit[i:]
-								break
-							}
-						}
-
-						if num, err := strconv.Atoi(numPart); err == nil {
-							currentMem = num
-							unit = unitPart
-						}
-					}
-
-					// If we couldn't parse the limit or it's very small, set a reasonable default
-					if currentMem == 0 || currentMem < 64 {
-						// For very small values, increase substantially
-						updatedContent = updateMemoryLimits(content, "256Mi")
-						fmt.Printf("Updating memory limit to 256Mi\n")
-					} else {
-						// Increase by 50%
-						newMem := int(float64(currentMem) * 1.5)
-						updatedContent = updateMemoryLimits(content, fmt.Sprintf("%d%s", newMem, unit))
-						fmt.Printf("Increasing memory limit from %s to %d%s\n", memoryLimit, newMem, unit)
-					}
+	updates, err := registry.Generate(ctx, toStrategyDiagnostic(diag), files)
+	if err != nil {
+		log.Errorw("remediation strategy failed", "error", err)
+		return []FileUpdate{}
+	}
 
-					// Add the updated file to our result
-					if updatedContent != content {
-						fileUpdate := FileUpdate{
-							Path:    name,
-							Content: updatedContent,
-						}
-						fileUpdates = append(fileUpdates, fileUpdate)
-						fmt.Printf("Added file update for %s\n", name)
-					}
-				}
-			}
-		}
+	fileUpdates := make([]FileUpdate, len(updates))
+	for i, u := range updates {
+		fileUpdates[i] = FileUpdate{Path: u.Path, Content: u.Content}
 	}
 
-	fmt.Printf("Generated %d file updates\n", len(fileUpdates))
-	fmt.Printf("=== END LOCAL RESPONSE ===\n\n")
+	log.Infow("generated local fallback file updates", "count", len(fileUpdates))
 
 	return fileUpdates
 }
 
-// updateMemoryLimits updates the memory limits in a YAML file
-func updateMemoryLimits(content string, newLimit string) string {
-	lines := strings.Split(content, "\n")
-	inResources := false
-	inLimits := false
-
-	for i, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Track if we're in the resources section
-		if strings.HasPrefix(trimmedLine, "resources:") {
-			inResources = true
-			continue
-		}
-
-		// Check indent level to see if we're still in resources
-		if inResources && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && trimmedLine != "" {
-			inResources = false
-			inLimits = false
-			continue
-		}
-
-		// Track if we're in the limits section
-		if inResources && strings.HasPrefix(trimmedLine, "limits:") {
-			inLimits = true
-			continue
-		}
-
-		// Check indent level to see if we're still in limits
-		if inLimits && strings.HasPrefix(trimmedLine, "requests:") {
-			inLimits = false
-			continue
-		}
-
-		// Update memory limit
-		if inLimits && strings.HasPrefix(trimmedLine, "memory:") {
-			indent := extractIndentation(line)
-			lines[i] = indent + "memory: " + newLimit
-		}
+// toStrategyDiagnostic converts the controller's diagnostic into the
+// strategies package's dependency-free Diagnostic type.
+func toStrategyDiagnostic(diag diagnostic) strategies.Diagnostic {
+	return strategies.Diagnostic{
+		Name:      diag.name,
+		Kind:      diag.kind,
+		Namespace: diag.namespace,
+		Error:     diag.error,
+		Solution:  diag.solution,
 	}
-
-	return strings.Join(lines, "\n")
 }
 
-// extractIndentation gets the whitespace prefix of a line
-func extractIndentation(line string) string {
-	indent := ""
-	for _, c := range line {
-		if c == ' ' || c == '\t' {
-			indent += string(c)
-		} else {
-			break
+// getStrategyRegistry lazily builds the remediation strategy registry on
+// first use, from the ordered, comma-separated REMEDIATION_STRATEGIES list
+// (see strategies.DefaultOrder for the default).
+func getStrategyRegistry(ctx context.Context) *strategies.Registry {
+	strategyRegistryOnce.Do(func() {
+		registry, err := strategies.NewRegistry(remediationStrategies)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("failed to initialize remediation strategies, local fallback will produce no updates", "error", err)
+			return
 		}
-	}
-	return indent
+		strategyRegistry = registry
+	})
+	return strategyRegistry
 }
 
-// sendToGHService sends file updates to the gh-service to create a pull request
-func sendToGHService(ctx context.Context, fileUpdates []FileUpdate, diag diagnostic) error {
-	fmt.Printf("Preparing to send updates to gh-service at %s\n", ghServiceUrl)
+// errGHServiceConflict indicates gh-service rejected a PR request because at
+// least one file's base SHA no longer matches the repository (HTTP 409),
+// meaning the file moved on since it was fetched for the AI prompt.
+var errGHServiceConflict = errors.New("gh-service reported a base SHA conflict")
+
+// sendToGHService sends file updates to the gh-service to create a pull request.
+// baseSHAs carries each file's expected current blob SHA, keyed by the same
+// path used in fileUpdates, so gh-service can detect a conflicting write.
+func sendToGHService(ctx context.Context, fileUpdates []FileUpdate, diag diagnostic, baseSHAs map[string]string) (string, error) {
+	log := logging.FromContext(ctx)
+	log.Infow("preparing to send updates to gh-service", "url", ghServiceUrl)
 
-	// Create a title for the PR with emoji
+	// Create a title for the PR
 	title := fmt.Sprintf("fix: Fixed %s issue in namespace %s", diag.name, diag.namespace)
 
 	// Create PR body with diagnostic information
-	body := fmt.Sprintf("This PR fixes an issue detected by K8sGPT for %s/%s in namespace %s. üåª\n\n",
+	body := fmt.Sprintf("This PR fixes an issue detected by K8sGPT for %s/%s in namespace %s.\n\n",
 		diag.kind, diag.name, diag.namespace)
 	body += fmt.Sprintf("**Error:** %s\n\n", diag.error)
 	body += fmt.Sprintf("**Solution:** %s\n\n", diag.solution)
+	if correlationID := logging.CorrelationID(ctx); correlationID != "" {
+		body += fmt.Sprintf("**Correlation ID:** `%s`\n\n", correlationID)
+	}
 	body += "Changes were automatically generated by Prof Tournesol."
 
 	// Create the files array in the format expected by gh-service
@@ -1002,10 +1387,11 @@ func sendToGHService(ctx context.Context, fileUpdates []FileUpdate, diag diagnos
 		if !strings.HasPrefix(path, fmt.Sprintf("apps/%s/", diag.namespace)) {
 			path = fmt.Sprintf("apps/%s/%s", diag.namespace, path)
 		}
-		
+
 		ghFiles[i] = map[string]string{
-			"path":    path,
-			"content": update.Content,
+			"path":     path,
+			"content":  update.Content,
+			"base_sha": baseSHAs[update.Path],
 		}
 	}
 
@@ -1023,14 +1409,14 @@ func sendToGHService(ctx context.Context, fileUpdates []FileUpdate, diag diagnos
 	// Marshal to JSON
 	jsonData, err := json.Marshal(prPayload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal PR payload: %w", err)
+		return "", fmt.Errorf("failed to marshal PR payload: %w", err)
 	}
 
 	// Create request to gh-service
 	reqUrl := fmt.Sprintf("%s/pull_requests", ghServiceUrl)
 	req, err := http.NewRequestWithContext(ctx, "POST", reqUrl, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -1038,60 +1424,395 @@ func sendToGHService(ctx context.Context, fileUpdates []FileUpdate, diag diagnos
 	// Send request
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request to gh-service: %w", err)
+		return "", fmt.Errorf("failed to send request to gh-service: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Check response status
+	if resp.StatusCode == http.StatusConflict {
+		return "", fmt.Errorf("%w: %s", errGHServiceConflict, respBody)
+	}
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("gh-service returned error status %d: %s", resp.StatusCode, respBody)
+		return "", fmt.Errorf("gh-service returned error status %d: %s", resp.StatusCode, respBody)
+	}
+
+	log.Debugw("gh-service response", "body", string(respBody))
+
+	var result struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		log.Warnw("failed to parse gh-service response for PR URL", "error", err)
+	}
+
+	return result.URL, nil
+}
+
+// tryUpdateFunc regenerates a single file's proposed content against its
+// current content, mirroring etcd's guaranteedUpdate apply function.
+type tryUpdateFunc func(path, currentContent string) (string, error)
+
+// ghConflictRetries counts gh-service base-SHA conflict retries across every
+// PR this process has opened. There's no metrics-scrape endpoint in this
+// controller yet, so it's surfaced as a process-lifetime counter through
+// structured logs rather than a real metric.
+var ghConflictRetries int64
+
+// sendToGHServiceWithRetry posts fileUpdates to gh-service under an
+// optimistic-concurrency retry loop modeled on etcd's updateState /
+// guaranteedUpdate: each file's current blob SHA is fetched fresh from
+// GitHub immediately before the PR is opened and sent as that file's
+// expected base_sha. If gh-service reports a conflict (errGHServiceConflict),
+// meaning a file moved on since fileUpdates was generated, every file is
+// re-fetched and tryUpdate is re-invoked against the new content, up to
+// aiMaxRetries attempts total. A file whose re-fetched content is unchanged
+// since the last attempt skips tryUpdate, since origState is already known
+// to be current and regenerating would just reproduce the same result.
+// origFiles is the path->content map that fileUpdates was originally
+// generated from (handleResult's fetchGitHubFiles result), used to seed that
+// comparison so the first SHA-fetch pass doesn't pay for a redundant
+// regeneration when nothing has moved since fileUpdates was computed.
+func sendToGHServiceWithRetry(ctx context.Context, diag diagnostic, fileUpdates []FileUpdate, origFiles map[string]string, tryUpdate tryUpdateFunc) (string, error) {
+	log := logging.FromContext(ctx)
+
+	current := make([]FileUpdate, len(fileUpdates))
+	copy(current, fileUpdates)
+	baseSHAs := make(map[string]string, len(current))
+	lastSeen := make(map[string]string, len(current))
+	for path, content := range origFiles {
+		lastSeen[path] = content
+	}
+
+	refresh := func(update *FileUpdate) error {
+		content, sha, err := fetchFileFromGitHub(ctx, diag.namespace, update.Path)
+		if err != nil {
+			return fmt.Errorf("failed to fetch current content of %s: %w", update.Path, err)
+		}
+		baseSHAs[update.Path] = sha
+
+		if seen, ok := lastSeen[update.Path]; ok && content == seen {
+			// origState hasn't moved since the last attempt, so the conflict
+			// must have been on a different file; reuse the already
+			// -generated content instead of paying for a pointless second
+			// AI/fallback call.
+			return nil
+		}
+		lastSeen[update.Path] = content
+
+		newContent, err := tryUpdate(update.Path, content)
+		if err != nil {
+			return fmt.Errorf("failed to regenerate %s against current content: %w", update.Path, err)
+		}
+		update.Content = newContent
+		return nil
+	}
+
+	for i := range current {
+		if err := refresh(&current[i]); err != nil {
+			return "", err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		prUrl, err := sendToGHService(ctx, current, diag, baseSHAs)
+		if err == nil {
+			return prUrl, nil
+		}
+		if !errors.Is(err, errGHServiceConflict) || attempt >= aiMaxRetries-1 {
+			return "", err
+		}
+
+		atomic.AddInt64(&ghConflictRetries, 1)
+		log.Warnw("gh-service reported a base SHA conflict, re-fetching and retrying",
+			"attempt", attempt+1, "max_retries", aiMaxRetries,
+			"total_conflict_retries", atomic.LoadInt64(&ghConflictRetries))
+
+		for i := range current {
+			if err := refresh(&current[i]); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// fetchFileFromGitHub fetches a single file's current content and blob SHA
+// directly from the GitHub Git Trees+Blobs API, the same API fetchGitHubFiles
+// uses to build the original AI prompt. It's used by sendToGHServiceWithRetry
+// to detect whether a file moved on after that prompt was built. relPath is
+// relative to apps/<namespace>/, matching the paths fetchGitHubFiles returns.
+func fetchFileFromGitHub(ctx context.Context, namespace, relPath string) (content, sha string, err error) {
+	commitSHA, err := resolveCommitSHA(ctx, githubBranch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve branch %s to a commit: %w", githubBranch, err)
+	}
+
+	tree, err := fetchGitTree(ctx, commitSHA)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch git tree for commit %s: %w", commitSHA, err)
+	}
+
+	fullPath := fmt.Sprintf("apps/%s/%s", namespace, relPath)
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" && entry.Path == fullPath {
+			content, err := fetchBlob(ctx, entry.SHA)
+			if err != nil {
+				return "", "", err
+			}
+			return content, entry.SHA, nil
+		}
+	}
+
+	return "", "", nil // file no longer exists in the tree; caller treats this as a fresh add
+}
+
+// secretLookingKeyPattern matches manifest keys whose value is likely a secret
+// (password, token, api key, etc.) so it can be masked before being logged.
+var secretLookingKeyPattern = regexp.MustCompile(`(?i)^\s*[\w-]*(password|token|secret|apikey|api_key|access_key)[\w-]*:\s*(.+?)\s*$`)
+
+// isGitHubActionsEnvironment reports whether the controller is running as a step
+// in a GitHub Actions job, in which case it should emit workflow commands instead
+// of delegating to the gh-service sidecar.
+func isGitHubActionsEnvironment() bool {
+	return strings.EqualFold(os.Getenv("GITHUB_ACTIONS"), "true") &&
+		(os.Getenv("GITHUB_STEP_SUMMARY") != "" || os.Getenv("GITHUB_OUTPUT") != "")
+}
+
+// sendToGitHubActions emits GitHub Actions workflow commands for a processed
+// diagnostic instead of opening a PR via gh-service. This lets users drop the
+// binary into a CI job to review K8sGPT results without the gh-service sidecar.
+func sendToGitHubActions(ctx context.Context, fileUpdates []FileUpdate, diag diagnostic) error {
+	log := logging.FromContext(ctx)
+
+	maskSecret(githubToken)
+	for _, update := range fileUpdates {
+		maskManifestSecrets(update.Content)
+	}
+
+	groupTitle := fmt.Sprintf("%s/%s (%s)", diag.namespace, diag.name, diag.kind)
+	fmt.Printf("::group::%s\n", groupTitle)
+
+	resourceRef := fmt.Sprintf("%s/%s", diag.namespace, diag.name)
+	if diag.error != "" {
+		emitAnnotation("error", resourceRef, 1, "K8sGPT diagnostic", diag.error)
+	}
+	if diag.solution != "" {
+		emitAnnotation("warning", resourceRef, 1, "Proposed solution", diag.solution)
+	}
+	for _, update := range fileUpdates {
+		emitAnnotation("notice", update.Path, 1, "Proposed patch",
+			fmt.Sprintf("Prof Tournesol suggests updating %s", update.Path))
+	}
+
+	fmt.Println("::endgroup::")
+
+	if err := appendGitHubStepSummary(diag, fileUpdates); err != nil {
+		log.Warnw("failed to write GITHUB_STEP_SUMMARY", "error", err)
+	}
+	if err := writeGitHubOutputs(diag, fileUpdates); err != nil {
+		log.Warnw("failed to write GITHUB_OUTPUT", "error", err)
+	}
+
+	return nil
+}
+
+// maskSecret emits an ::add-mask:: workflow command so the given value is
+// redacted from all subsequent Actions logs. No-op for empty values.
+func maskSecret(value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("::add-mask::%s\n", value)
+}
+
+// maskManifestSecrets scans manifest content line by line for secret-looking
+// keys (password, token, secret, apiKey, ...) and masks their values.
+func maskManifestSecrets(content string) {
+	for _, line := range strings.Split(content, "\n") {
+		if m := secretLookingKeyPattern.FindStringSubmatch(line); m != nil {
+			maskSecret(strings.Trim(m[2], `"'`))
+		}
+	}
+}
+
+// escapeActionsData escapes a workflow command's data segment (the part after
+// the final "::"), per the GitHub Actions workflow-command encoding rules.
+func escapeActionsData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	return s
+}
+
+// escapeActionsProperty escapes a workflow command property value (e.g. file=,
+// line=, title=), which additionally requires escaping ":" and ",".
+func escapeActionsProperty(s string) string {
+	s = escapeActionsData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	s = strings.ReplaceAll(s, ",", "%2C")
+	return s
+}
+
+// emitAnnotation prints a GitHub Actions "::notice::"/"::warning::"/"::error::"
+// workflow command annotated with a file and line for the Actions UI.
+func emitAnnotation(level, file string, line int, title, message string) {
+	fmt.Printf("::%s file=%s,line=%d,title=%s::%s\n",
+		level, escapeActionsProperty(file), line, escapeActionsProperty(title), escapeActionsData(message))
+}
+
+// appendGitHubStepSummary appends a Markdown table row describing the
+// diagnostic and its proposed patch to the job's step summary file.
+func appendGitHubStepSummary(diag diagnostic, fileUpdates []FileUpdate) error {
+	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryPath == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_STEP_SUMMARY: %w", err)
+	}
+	defer f.Close()
+
+	githubActionsSummaryMutex.Lock()
+	if !githubActionsSummaryWritten {
+		if _, err := f.WriteString("## Prof Tournesol results\n\n| Kind | Namespace | Error | Proposed patch |\n| --- | --- | --- | --- |\n"); err != nil {
+			githubActionsSummaryMutex.Unlock()
+			return err
+		}
+		githubActionsSummaryWritten = true
+	}
+	githubActionsSummaryMutex.Unlock()
+
+	var patchedFiles []string
+	for _, update := range fileUpdates {
+		patchedFiles = append(patchedFiles, update.Path)
+	}
+
+	row := fmt.Sprintf("| %s | %s | %s | %s |\n",
+		markdownTableCell(diag.kind), markdownTableCell(diag.namespace),
+		markdownTableCell(diag.error), markdownTableCell(strings.Join(patchedFiles, ", ")))
+
+	_, err = f.WriteString(row)
+	return err
+}
+
+// markdownTableCell escapes a value for safe inclusion in a Markdown table cell.
+func markdownTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+// writeGitHubOutputs writes the structured step outputs (file_updates_json,
+// namespace, kind) to GITHUB_OUTPUT using the multi-line "name<<DELIM" framing.
+func writeGitHubOutputs(diag diagnostic, fileUpdates []FileUpdate) error {
+	outputPath := os.Getenv("GITHUB_OUTPUT")
+	if outputPath == "" {
+		return nil
+	}
+
+	fileUpdatesJSON, err := json.Marshal(fileUpdates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal file updates: %w", err)
+	}
+
+	f, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+	}
+	defer f.Close()
+
+	const delim = "PROF_TOURNESOL_EOF"
+	outputs := map[string]string{
+		"file_updates_json": string(fileUpdatesJSON),
+		"namespace":         diag.namespace,
+		"kind":              diag.kind,
+	}
+
+	for name, value := range outputs {
+		if _, err := fmt.Fprintf(f, "%s<<%s\n%s\n%s\n", name, delim, value, delim); err != nil {
+			return fmt.Errorf("failed to write output %s: %w", name, err)
+		}
 	}
 
-	fmt.Printf("gh-service response: %s\n", respBody)
 	return nil
 }
 
 func main() {
-	// Log startup configuration
-	fmt.Printf("Prof Tournesol controller starting with configuration:\n")
-	fmt.Printf("- GitHub Repository: %s/%s (branch: %s)\n", githubOwner, githubRepo, githubBranch)
-	fmt.Printf("- GitHub Path: apps/<namespace> using GitHub API\n")
-	fmt.Printf("- AI Base URL: %s\n", aiBaseUrl)
-	fmt.Printf("- AI Endpoint: %s\n", aiEndpointUrl)
-	fmt.Printf("- AI Model: %s\n", aiModel)
-	fmt.Printf("- AI Request Timeout: %d seconds\n", aiTimeoutSecs)
-	fmt.Printf("- AI Max Retries: %d\n", aiMaxRetries)
-	fmt.Printf("- AI Health Check Enabled: %v\n", aiHealthCheck)
-	fmt.Printf("- Local Fallback Enabled: %v\n", useFallback)
-
-	// Log the GH service URL configuration
-	fmt.Printf("- GH Service URL: %s\n", ghServiceUrl)
+	flag.Parse()
+	if *logLevelFlag != "" {
+		logLevel = *logLevelFlag
+	}
+	if *applyModeFlag != "" {
+		applyMode = *applyModeFlag
+	}
+
+	// Build the package-wide logger before anything else so every subsystem
+	// below (including the early dedupe compaction goroutine) can use it.
+	var err error
+	logger, err = logging.New(logLevel, logJSON)
+	if err != nil {
+		fmt.Printf("Error building logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	logger.Infow("Prof Tournesol controller starting",
+		"github_repo", fmt.Sprintf("%s/%s", githubOwner, githubRepo), "github_branch", githubBranch,
+		"ai_base_url", aiBaseUrl, "ai_endpoint", aiEndpointUrl, "ai_model", aiModel,
+		"ai_timeout_seconds", aiTimeoutSecs, "ai_max_retries", aiMaxRetries,
+		"ai_health_check", aiHealthCheck, "ai_stream", aiStream, "use_fallback", useFallback,
+		"gh_service_url", ghServiceUrl, "log_level", logLevel, "log_json", logJSON,
+		"audit_backend", auditBackend, "apply_mode", applyMode)
 
 	// Use in-cluster config
 	config, err := clientcmd.BuildConfigFromFlags("", "")
 	if err != nil {
-		fmt.Printf("Error building kubeconfig: %v\n", err)
+		logger.Errorw("failed to build kubeconfig", "error", err)
 		os.Exit(1)
 	}
 
 	// Create dynamic client for CRDs
-	dynamicClient, err := dynamic.NewForConfig(config)
+	dynamicClient, err = dynamic.NewForConfig(config)
 	if err != nil {
-		fmt.Printf("Error creating dynamic client: %v\n", err)
+		logger.Errorw("failed to create dynamic client", "error", err)
 		os.Exit(1)
 	}
 
-	// Define the GVR for Result resources
-	gvr := schema.GroupVersionResource{
-		Group:    "core.k8sgpt.ai",
-		Version:  "v1alpha1",
-		Resource: "results",
+	var allowedDangerousKinds []string
+	for _, kind := range strings.Split(applyAllowedDangerousKinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			allowedDangerousKinds = append(allowedDangerousKinds, kind)
+		}
+	}
+	resourceApplier = applier.New(dynamicClient, allowedDangerousKinds)
+
+	// Set up signal handling channel early so it's available to goroutines
+	// started before the informer (e.g. dedupe compaction).
+	stopCh := make(chan struct{})
+
+	// Initialize the dedupe store so restarts don't re-open duplicate PRs
+	dedupeStore, err = newDedupeStore(dynamicClient)
+	if err != nil {
+		logger.Warnw("failed to initialize dedupe store, duplicate processing is possible", "error", err)
+		dedupeStore = nil // drop any typed-nil store so handleResult skips dedupe
+	}
+	if compactable, ok := dedupeStore.(dedupe.Compactable); ok {
+		go runDedupeCompaction(compactable, stopCh)
+	}
+
+	// Initialize the audit sink so AI round-trips and fallback responses are
+	// recorded for postmortem review
+	auditSink, err = newAuditSink(context.Background())
+	if err != nil {
+		logger.Warnw("failed to initialize audit sink, audit records will be discarded", "error", err)
+		auditSink = audit.NoopSink{}
 	}
 
 	// Create dynamic informer factory
@@ -1104,7 +1825,7 @@ func main() {
 	)
 
 	// Get informer for Result resources
-	informer := factory.ForResource(gvr).Informer()
+	informer := factory.ForResource(resultGVR).Informer()
 
 	// Set up event handlers
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -1119,13 +1840,12 @@ func main() {
 			if oldVersion != newVersion {
 				handleResult(newObj.(*unstructured.Unstructured))
 			} else {
-				fmt.Printf("Skipping unchanged resource version: %s\n", newVersion)
+				logger.Debugw("skipping unchanged resource version", "resource_version", newVersion)
 			}
 		},
 	})
 
 	// Set up signal handling
-	stopCh := make(chan struct{})
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -1133,7 +1853,7 @@ func main() {
 
 	go func() {
 		<-signalCh
-		fmt.Println("Shutting down Prof Tournesol controller...")
+		logger.Infow("shutting down Prof Tournesol controller")
 		close(stopCh)
 	}()
 
@@ -1144,15 +1864,15 @@ func main() {
 
 		for {
 			<-usr1Ch
-			fmt.Println("Received USR1 signal - testing AI endpoint...")
+			logger.Infow("received USR1 signal, testing AI endpoint")
 			testCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			healthy := checkAIEndpointHealth(testCtx)
 			cancel()
 
 			if healthy {
-				fmt.Println("‚úÖ AI endpoint test succeeded")
+				logger.Infow("AI endpoint test succeeded")
 			} else {
-				fmt.Println("‚ùå AI endpoint test failed")
+				logger.Warnw("AI endpoint test failed")
 			}
 		}
 	}()
@@ -1164,14 +1884,13 @@ func main() {
 		cancel()
 
 		if healthy {
-			fmt.Printf("‚úÖ Initial AI endpoint health check passed\n")
+			logger.Infow("initial AI endpoint health check passed")
 		} else {
-			fmt.Printf("‚ö†Ô∏è Initial AI endpoint health check failed - will use local fallback if needed\n")
-			fmt.Printf("üí° TIP: Send SIGUSR1 signal to test AI endpoint: kill -USR1 <pid>\n")
+			logger.Warnw("initial AI endpoint health check failed, will use local fallback if needed")
 		}
 	}
 
-	fmt.Printf("Prof Tournesol controller started - watching namespace '%s'\n", namespace)
+	logger.Infow("Prof Tournesol controller started", "namespace", namespace)
 
 	// Start the informer and wait
 	go informer.Run(stopCh)
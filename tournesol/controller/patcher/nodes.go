@@ -0,0 +1,172 @@
+package patcher
+
+import (
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scalarTag returns the short YAML tag to give a freshly-created scalar node
+// holding value, so a plain integer like "35" is emitted unquoted the same
+// way a decoded node would be.
+func scalarTag(value string) string {
+	if _, err := strconv.Atoi(value); err == nil {
+		return "!!int"
+	}
+	return "!!str"
+}
+
+// documentRoot returns doc's root mapping node, unwrapping the
+// yaml.DocumentNode the decoder produces for each parsed document.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// kindOf returns root's "kind" field, or "" if root isn't a mapping with one.
+func kindOf(root *yaml.Node) string {
+	value, _ := getScalar(root, "kind")
+	return value
+}
+
+// getMapping returns mapping's value for key if it exists and is itself a
+// mapping, or nil otherwise.
+func getMapping(mapping *yaml.Node, key string) *yaml.Node {
+	value := get(mapping, key)
+	if value == nil || value.Kind != yaml.MappingNode {
+		return nil
+	}
+	return value
+}
+
+// getOrCreateMapping returns mapping's value for key, creating an empty
+// mapping under key if it's missing or of the wrong kind.
+func getOrCreateMapping(mapping *yaml.Node, key string) *yaml.Node {
+	if value := getMapping(mapping, key); value != nil {
+		return value
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setNode(mapping, key, value)
+	return value
+}
+
+// getOrCreateSequence returns mapping's value for key, creating an empty
+// sequence under key if it's missing or of the wrong kind.
+func getOrCreateSequence(mapping *yaml.Node, key string) *yaml.Node {
+	value := get(mapping, key)
+	if value != nil && value.Kind == yaml.SequenceNode {
+		return value
+	}
+
+	value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	setNode(mapping, key, value)
+	return value
+}
+
+// getScalar returns mapping's value for key as a string, and whether it was
+// found and is a scalar.
+func getScalar(mapping *yaml.Node, key string) (string, bool) {
+	value := get(mapping, key)
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	return value.Value, true
+}
+
+// setScalar sets mapping's value for key to a scalar holding value, creating
+// the key if it's missing. An existing scalar is updated in place (leaving
+// its tag and any attached comments untouched) rather than replaced, so a
+// trailing "# ..." comment on the line survives the edit. It reports whether
+// the value actually changed.
+func setScalar(mapping *yaml.Node, key, value string) bool {
+	if existing := get(mapping, key); existing != nil && existing.Kind == yaml.ScalarNode {
+		if existing.Value == value {
+			return false
+		}
+		existing.Value = value
+		return true
+	}
+
+	setNode(mapping, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: scalarTag(value), Value: value})
+	return true
+}
+
+// get returns mapping's value node for key, or nil if mapping isn't a
+// mapping or doesn't have key.
+func get(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setNode sets mapping's value node for key to value, replacing it in place
+// (to preserve the key node's comments) if key already exists, or appending
+// a new key/value pair otherwise.
+func setNode(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+}
+
+// findContainers returns the containers (from spec.template.spec.containers
+// for a workload, or spec.containers for a bare Pod) in doc whose name
+// matches name, or every container if name is "".
+func findContainers(doc *yaml.Node, name string) []*yaml.Node {
+	root := documentRoot(doc)
+	if root == nil {
+		return nil
+	}
+
+	spec := getMapping(root, "spec")
+	if spec == nil {
+		return nil
+	}
+
+	if workloadKinds[kindOf(root)] {
+		template := getMapping(spec, "template")
+		if template == nil {
+			return nil
+		}
+		spec = getMapping(template, "spec")
+		if spec == nil {
+			return nil
+		}
+	}
+
+	containers := get(spec, "containers")
+	if containers == nil || containers.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var matched []*yaml.Node
+	for _, containerNode := range containers.Content {
+		if name == "" {
+			matched = append(matched, containerNode)
+			continue
+		}
+		if containerName, ok := getScalar(containerNode, "name"); ok && containerName == name {
+			matched = append(matched, containerNode)
+		}
+	}
+	return matched
+}
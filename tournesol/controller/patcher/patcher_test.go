@@ -0,0 +1,342 @@
+package patcher
+
+import (
+	"strings"
+	"testing"
+)
+
+const deploymentManifest = `# Owned by team-platform, do not edit namespace.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: web
+          image: web:1.2.3
+          ports:
+            - containerPort: 8080
+            - containerPort: 8081
+          resources:
+            limits:
+              memory: 128Mi # bumped once already
+            requests:
+              cpu: 100m
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: 8080
+            initialDelaySeconds: 5
+            failureThreshold: 3
+`
+
+const multiContainerDeploymentManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: web:1.2.3
+          resources:
+            limits:
+              memory: 128Mi
+          livenessProbe:
+            initialDelaySeconds: 5
+        - name: sidecar
+          image: sidecar:1
+          resources:
+            limits:
+              memory: 512Mi
+        - name: logger
+          image: logger:1
+`
+
+const statefulSetManifest = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+    name: db
+spec:
+    template:
+        spec:
+            containers:
+                - name: db
+                  image: db:9
+                  resources:
+                      limits:
+                          memory: 512Mi
+`
+
+const daemonSetManifest = `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          image: agent:1
+          resources:
+            limits:
+              memory: 64Mi
+`
+
+func TestSetResource_PreservesCommentsAndFormatting(t *testing.T) {
+	p, err := New(deploymentManifest)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	current, ok := p.GetResource("", "limits", "memory")
+	if !ok || current != "128Mi" {
+		t.Fatalf("GetResource: got (%q, %v), want (128Mi, true)", current, ok)
+	}
+
+	changed, err := p.SetResource("", "limits", "memory", "256Mi")
+	if err != nil {
+		t.Fatalf("SetResource: %v", err)
+	}
+	if !changed {
+		t.Fatalf("SetResource: expected a change")
+	}
+
+	out, err := p.String()
+	if err != nil {
+		t.Fatalf("String: %v", err)
+	}
+
+	if !strings.Contains(out, "memory: 256Mi") {
+		t.Errorf("output missing updated memory limit:\n%s", out)
+	}
+	if !strings.Contains(out, "# Owned by team-platform, do not edit namespace.") {
+		t.Errorf("output lost file header comment:\n%s", out)
+	}
+	if !strings.Contains(out, "# bumped once already") {
+		t.Errorf("output lost inline comment on the field it edited:\n%s", out)
+	}
+	// The CPU request, ports, and probe are untouched by a memory-only edit.
+	if !strings.Contains(out, "cpu: 100m") {
+		t.Errorf("output lost unrelated cpu request:\n%s", out)
+	}
+	if !strings.Contains(out, "containerPort: 8080") || !strings.Contains(out, "containerPort: 8081") {
+		t.Errorf("output lost port list entries:\n%s", out)
+	}
+	if strings.Index(out, "containerPort: 8080") > strings.Index(out, "containerPort: 8081") {
+		t.Errorf("output reordered the port list:\n%s", out)
+	}
+}
+
+func TestSetResource_MultiDocumentPreservesOrderAndUntouchedDocs(t *testing.T) {
+	content := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+` + deploymentManifest
+
+	p, err := New(content)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	changed, err := p.SetResource("", "limits", "memory", "256Mi")
+	if err != nil {
+		t.Fatalf("SetResource: %v", err)
+	}
+	if !changed {
+		t.Fatalf("SetResource: expected a change")
+	}
+
+	out, err := p.String()
+	if err != nil {
+		t.Fatalf("String: %v", err)
+	}
+
+	serviceIdx := strings.Index(out, "kind: Service")
+	deploymentIdx := strings.Index(out, "kind: Deployment")
+	if serviceIdx == -1 || deploymentIdx == -1 || serviceIdx > deploymentIdx {
+		t.Errorf("documents were not preserved in order:\n%s", out)
+	}
+	if !strings.Contains(out, "app: web") {
+		t.Errorf("Service document was corrupted:\n%s", out)
+	}
+	if !strings.Contains(out, "memory: 256Mi") {
+		t.Errorf("Deployment document was not patched:\n%s", out)
+	}
+}
+
+func TestSetResource_StatefulSetAndDaemonSet(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest string
+	}{
+		{"StatefulSet", statefulSetManifest},
+		{"DaemonSet", daemonSetManifest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := New(tc.manifest)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			changed, err := p.SetResource("", "limits", "memory", "256Mi")
+			if err != nil {
+				t.Fatalf("SetResource: %v", err)
+			}
+			if !changed {
+				t.Fatalf("SetResource: expected a change")
+			}
+
+			out, err := p.String()
+			if err != nil {
+				t.Fatalf("String: %v", err)
+			}
+			if !strings.Contains(out, "memory: 256Mi") {
+				t.Errorf("output missing updated memory limit:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestSetProbeField_RoundTrips(t *testing.T) {
+	p, err := New(deploymentManifest)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	value, ok := p.GetProbeField("", "livenessProbe", "initialDelaySeconds")
+	if !ok || value != "5" {
+		t.Fatalf("GetProbeField: got (%q, %v), want (5, true)", value, ok)
+	}
+
+	changed, err := p.SetProbeField("", "livenessProbe", "initialDelaySeconds", "35")
+	if err != nil {
+		t.Fatalf("SetProbeField: %v", err)
+	}
+	if !changed {
+		t.Fatalf("SetProbeField: expected a change")
+	}
+
+	out, err := p.String()
+	if err != nil {
+		t.Fatalf("String: %v", err)
+	}
+	if !strings.Contains(out, "initialDelaySeconds: 35") {
+		t.Errorf("output missing updated initialDelaySeconds:\n%s", out)
+	}
+	if !strings.Contains(out, "path: /healthz") {
+		t.Errorf("output lost unrelated httpGet probe fields:\n%s", out)
+	}
+}
+
+func TestSetEnvVar_AppendsAndUpdates(t *testing.T) {
+	p, err := New(deploymentManifest)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := p.SetEnvVar("web", "LOG_LEVEL", "debug"); err != nil {
+		t.Fatalf("SetEnvVar (append): %v", err)
+	}
+	if _, err := p.SetEnvVar("web", "LOG_LEVEL", "trace"); err != nil {
+		t.Fatalf("SetEnvVar (update): %v", err)
+	}
+
+	out, err := p.String()
+	if err != nil {
+		t.Fatalf("String: %v", err)
+	}
+	if strings.Count(out, "LOG_LEVEL") != 1 {
+		t.Errorf("expected exactly one LOG_LEVEL entry after update, got output:\n%s", out)
+	}
+	if !strings.Contains(out, "value: trace") {
+		t.Errorf("output missing updated env value:\n%s", out)
+	}
+}
+
+func TestAdjustResource_PerContainerNoFabrication(t *testing.T) {
+	p, err := New(multiContainerDeploymentManifest)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	changed, err := p.AdjustResource("", "limits", "memory", func(current string) string {
+		return current + "-bumped"
+	})
+	if err != nil {
+		t.Fatalf("AdjustResource: %v", err)
+	}
+	if !changed {
+		t.Fatalf("AdjustResource: expected a change")
+	}
+
+	out, err := p.String()
+	if err != nil {
+		t.Fatalf("String: %v", err)
+	}
+
+	// web and sidecar each keep their own bumped value...
+	if !strings.Contains(out, "memory: 128Mi-bumped") {
+		t.Errorf("output missing web's own adjusted limit:\n%s", out)
+	}
+	if !strings.Contains(out, "memory: 512Mi-bumped") {
+		t.Errorf("output missing sidecar's own adjusted limit:\n%s", out)
+	}
+	// ...and logger, which never had a memory limit, doesn't gain one.
+	if strings.Contains(out, "logger") && strings.Contains(out[strings.Index(out, "name: logger"):], "resources:") {
+		t.Errorf("output fabricated a resources section on logger, which had none:\n%s", out)
+	}
+}
+
+func TestAdjustProbeField_SkipsContainersWithoutProbe(t *testing.T) {
+	p, err := New(multiContainerDeploymentManifest)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	changed, err := p.AdjustProbeField("", "livenessProbe", "initialDelaySeconds", func(string) string {
+		return "35"
+	})
+	if err != nil {
+		t.Fatalf("AdjustProbeField: %v", err)
+	}
+	if !changed {
+		t.Fatalf("AdjustProbeField: expected a change")
+	}
+
+	out, err := p.String()
+	if err != nil {
+		t.Fatalf("String: %v", err)
+	}
+	if !strings.Contains(out, "initialDelaySeconds: 35") {
+		t.Errorf("output missing updated initialDelaySeconds:\n%s", out)
+	}
+	if strings.Contains(out, "sidecar") && strings.Contains(out[strings.Index(out, "name: sidecar"):], "livenessProbe") {
+		t.Errorf("output fabricated a livenessProbe on sidecar, which had none:\n%s", out)
+	}
+}
+
+func TestSetResource_NoMatchingContainerIsNoOp(t *testing.T) {
+	p, err := New(deploymentManifest)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	changed, err := p.SetResource("sidecar", "limits", "memory", "256Mi")
+	if err != nil {
+		t.Fatalf("SetResource: %v", err)
+	}
+	if changed {
+		t.Errorf("SetResource: expected no change for a container name that doesn't exist")
+	}
+}
@@ -0,0 +1,97 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/patcher"
+)
+
+func init() {
+	register("crashloopbackoff", func() RemediationStrategy { return &crashLoopStrategy{} })
+}
+
+// crashLoopStrategy tunes a container's liveness probe when K8sGPT reports
+// CrashLoopBackOff, on the theory that a probe firing before the app has
+// finished starting is a common cause of a restart loop.
+type crashLoopStrategy struct{}
+
+func (s *crashLoopStrategy) Name() string { return "crashloopbackoff" }
+
+func (s *crashLoopStrategy) Match(diag Diagnostic) bool {
+	lower := strings.ToLower(diag.Solution + " " + diag.Error)
+	return strings.Contains(lower, "crashloopbackoff") || strings.Contains(lower, "crash loop")
+}
+
+// Generate widens every container's existing livenessProbe
+// initialDelaySeconds and failureThreshold so a slow-starting container gets
+// more time before the probe starts counting failures.
+func (s *crashLoopStrategy) Generate(ctx context.Context, _ Diagnostic, files map[string]string) ([]FileUpdate, error) {
+	log := logging.FromContext(ctx)
+	log.Debugw("running crashloopbackoff strategy")
+
+	var fileUpdates []FileUpdate
+
+	for name, content := range files {
+		if !isYAMLFile(name) || !strings.Contains(content, "livenessProbe:") {
+			continue
+		}
+
+		p, err := patcher.New(content)
+		if err != nil {
+			log.Warnw("skipping file that failed to parse", "path", name, "error", err)
+			continue
+		}
+
+		changed, err := widenProbeField(p, "", "livenessProbe", "initialDelaySeconds", addInt(30))
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch %s: %w", name, err)
+		}
+		thresholdChanged, err := widenProbeField(p, "", "livenessProbe", "failureThreshold", addInt(2))
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch %s: %w", name, err)
+		}
+		changed = changed || thresholdChanged
+		if !changed {
+			continue
+		}
+
+		updatedContent, err := p.String()
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-serialize %s: %w", name, err)
+		}
+
+		fileUpdates = append(fileUpdates, FileUpdate{Path: name, Content: updatedContent})
+		log.Infow("widened livenessProbe tuning", "path", name)
+	}
+
+	log.Infow("crashloopbackoff strategy generated file updates", "count", len(fileUpdates))
+
+	return fileUpdates, nil
+}
+
+// addInt returns an adjust func that adds delta to the current value,
+// floored at delta itself so a missing/zero value still ends up useful.
+func addInt(delta int) func(current int) int {
+	return func(current int) int {
+		if current < 0 {
+			return delta
+		}
+		return current + delta
+	}
+}
+
+// widenProbeField widens field on every container matching container (or
+// every container, if container is "") that already has probe, passing each
+// container's own current value (0 if absent or unparsable) through adjust
+// independently, so a container whose probe is already tuned generously
+// isn't overwritten with another container's smaller adjusted value.
+func widenProbeField(p *patcher.Patcher, container, probe, field string, adjust func(current int) int) (bool, error) {
+	return p.AdjustProbeField(container, probe, field, func(current string) string {
+		n, _ := strconv.Atoi(current)
+		return strconv.Itoa(adjust(n))
+	})
+}
@@ -0,0 +1,127 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/patcher"
+)
+
+func init() {
+	register("failedscheduling", func() RemediationStrategy { return &failedSchedulingStrategy{} })
+}
+
+// failedSchedulingStrategy relaxes a container's CPU request when K8sGPT
+// reports the scheduler couldn't find a node with enough allocatable
+// resources, the most common cause of FailedScheduling.
+type failedSchedulingStrategy struct{}
+
+func (s *failedSchedulingStrategy) Name() string { return "failedscheduling" }
+
+func (s *failedSchedulingStrategy) Match(diag Diagnostic) bool {
+	lower := strings.ToLower(diag.Solution + " " + diag.Error)
+	return strings.Contains(lower, "failedscheduling") || strings.Contains(lower, "insufficient cpu") ||
+		strings.Contains(lower, "insufficient memory") || strings.Contains(lower, "didn't match node selector") ||
+		strings.Contains(lower, "didn't tolerate")
+}
+
+// Generate halves the CPU request of every container in files that has one
+// when diag reports insufficient CPU, and logs nodeSelector/tolerations hints that need a human decision (the controller
+// has no view of cluster node labels or taints).
+func (s *failedSchedulingStrategy) Generate(ctx context.Context, diag Diagnostic, files map[string]string) ([]FileUpdate, error) {
+	log := logging.FromContext(ctx)
+	log.Debugw("running failedscheduling strategy")
+
+	lower := strings.ToLower(diag.Solution + " " + diag.Error)
+	var fileUpdates []FileUpdate
+
+	for name, content := range files {
+		if !isYAMLFile(name) {
+			continue
+		}
+
+		if strings.Contains(lower, "insufficient cpu") {
+			updated, err := halveCPURequest(log, name, content)
+			if err != nil {
+				return nil, err
+			}
+			if updated != "" {
+				fileUpdates = append(fileUpdates, FileUpdate{Path: name, Content: updated})
+				log.Infow("halved CPU request", "path", name)
+			}
+		}
+
+		if strings.Contains(lower, "didn't match node selector") && strings.Contains(content, "nodeSelector:") {
+			log.Infow("nodeSelector excludes every available node; verify the label still exists on a schedulable node", "path", name)
+		}
+
+		if strings.Contains(lower, "didn't tolerate") && !strings.Contains(content, "tolerations:") {
+			log.Infow("no tolerations configured; add one matching the target node's taint if scheduling onto tainted nodes is intended", "path", name)
+		}
+	}
+
+	log.Infow("failedscheduling strategy generated file updates", "count", len(fileUpdates))
+
+	return fileUpdates, nil
+}
+
+// halveCPURequest halves resources.requests.cpu on every container that
+// already has one set, independently, returning the re-serialized content,
+// or "" if nothing changed. Containers with no CPU request are left alone
+// instead of having one fabricated, and a container with its own (already
+// smaller) request isn't overwritten with another container's halved value.
+func halveCPURequest(log *zap.SugaredLogger, name, content string) (string, error) {
+	p, err := patcher.New(content)
+	if err != nil {
+		log.Warnw("skipping file that failed to parse", "path", name, "error", err)
+		return "", nil
+	}
+
+	changed, err := p.AdjustResource("", "requests", "cpu", func(current string) string {
+		if halved := halveCPUValue(current); halved != "" {
+			return halved
+		}
+		return current
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to patch %s: %w", name, err)
+	}
+	if !changed {
+		return "", nil
+	}
+
+	updated, err := p.String()
+	if err != nil {
+		return "", fmt.Errorf("failed to re-serialize %s: %w", name, err)
+	}
+	return updated, nil
+}
+
+// halveCPUValue halves a CPU quantity like "500m" or "1", returning its
+// millicore form floored at "10m". Returns "" if value can't be parsed.
+func halveCPUValue(value string) string {
+	var millis int
+	if strings.HasSuffix(value, "m") {
+		n := 0
+		if _, err := fmt.Sscanf(value, "%dm", &n); err != nil {
+			return ""
+		}
+		millis = n
+	} else {
+		var cores float64
+		if _, err := fmt.Sscanf(value, "%g", &cores); err != nil {
+			return ""
+		}
+		millis = int(cores * 1000)
+	}
+
+	half := millis / 2
+	if half < 10 {
+		half = 10
+	}
+	return fmt.Sprintf("%dm", half)
+}
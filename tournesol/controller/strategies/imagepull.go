@@ -0,0 +1,58 @@
+package strategies
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+)
+
+func init() {
+	register("imagepullbackoff", func() RemediationStrategy { return &imagePullStrategy{} })
+}
+
+// imagePullStrategy reports on a container image K8sGPT couldn't pull. It
+// can't guess the right image or tag, so unlike the other strategies it
+// doesn't propose a file edit — it annotates the error with the concrete
+// image reference and the registry-auth fix most likely to apply.
+type imagePullStrategy struct{}
+
+func (s *imagePullStrategy) Name() string { return "imagepullbackoff" }
+
+func (s *imagePullStrategy) Match(diag Diagnostic) bool {
+	lower := strings.ToLower(diag.Solution + " " + diag.Error)
+	return strings.Contains(lower, "imagepullbackoff") || strings.Contains(lower, "errimagepull") ||
+		strings.Contains(lower, "image pull")
+}
+
+// Generate never edits files: it only logs the offending image reference,
+// whether imagePullSecrets is already configured, and the likely fix
+// (correct the tag, or add imagePullSecrets for a private registry).
+func (s *imagePullStrategy) Generate(ctx context.Context, diag Diagnostic, files map[string]string) ([]FileUpdate, error) {
+	log := logging.FromContext(ctx)
+	log.Infow("running imagepullbackoff strategy", "namespace", diag.Namespace, "name", diag.Name, "error", diag.Error)
+
+	for name, content := range files {
+		if !isYAMLFile(name) {
+			continue
+		}
+
+		for _, line := range strings.Split(content, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "image:") {
+				log.Infow("container declares image", "path", name, "image", strings.TrimSpace(strings.TrimPrefix(trimmed, "image:")))
+			}
+		}
+
+		if strings.Contains(content, "imagePullSecrets:") {
+			log.Infow("imagePullSecrets already configured; check the secret contains valid registry credentials", "path", name)
+		} else {
+			log.Infow("no imagePullSecrets configured; if this image is in a private registry, add one referencing a docker-registry Secret", "path", name)
+		}
+	}
+
+	// No safe automated edit exists without knowing the correct tag or
+	// registry credentials, so this strategy intentionally returns no
+	// file updates.
+	return nil, nil
+}
@@ -0,0 +1,109 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+	"github.com/mfernd/prof-tournesol/tournesol/controller/patcher"
+)
+
+func init() {
+	register("oom", func() RemediationStrategy { return &oomStrategy{} })
+}
+
+// oomStrategy bumps a container's memory limit when K8sGPT reports an
+// OOMKilled container, the original built-in remediation.
+type oomStrategy struct{}
+
+func (s *oomStrategy) Name() string { return "oom" }
+
+// Match reports whether diag describes an OOM kill.
+func (s *oomStrategy) Match(diag Diagnostic) bool {
+	lower := strings.ToLower(diag.Solution + " " + diag.Error)
+	return strings.Contains(lower, "oomkilled") || strings.Contains(lower, "out of memory")
+}
+
+// Generate increases the memory limit of every container in files that
+// already has one: to 256Mi if the current limit is very small or
+// unparsable, otherwise by 50%. Containers with no memory limit are left
+// without one.
+func (s *oomStrategy) Generate(ctx context.Context, _ Diagnostic, files map[string]string) ([]FileUpdate, error) {
+	log := logging.FromContext(ctx)
+	log.Debugw("running oom strategy")
+
+	var fileUpdates []FileUpdate
+
+	for name, content := range files {
+		log.Debugw("processing file", "path", name)
+
+		if !isYAMLFile(name) {
+			continue
+		}
+
+		p, err := patcher.New(content)
+		if err != nil {
+			log.Warnw("skipping file that failed to parse", "path", name, "error", err)
+			continue
+		}
+
+		// Adjust each container's own memory limit independently: a
+		// container with no limit set keeps none (no fabricating a limit
+		// on a sidecar just because the OOMing container got bumped), and a
+		// 512Mi sidecar doesn't get clobbered with the primary container's
+		// new value.
+		changed, err := p.AdjustResource("", "limits", "memory", nextMemoryLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch %s: %w", name, err)
+		}
+		if !changed {
+			continue
+		}
+
+		updatedContent, err := p.String()
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-serialize %s: %w", name, err)
+		}
+
+		log.Infow("increased memory limit", "path", name)
+		fileUpdates = append(fileUpdates, FileUpdate{Path: name, Content: updatedContent})
+	}
+
+	log.Infow("oom strategy generated file updates", "count", len(fileUpdates))
+
+	return fileUpdates, nil
+}
+
+// nextMemoryLimit proposes a new value for a "6Mi"/"256Mi"/"1Gi"-style
+// memory quantity: 256Mi if it's missing or very small, otherwise +50%.
+func nextMemoryLimit(current string) string {
+	numPart := ""
+	unitPart := ""
+	for i, c := range current {
+		if c >= '0' && c <= '9' {
+			numPart += string(c)
+		} else {
+			unitPart = current[i:]
+			break
+		}
+	}
+
+	amount, err := strconv.Atoi(numPart)
+	if err == nil {
+		// Express gigabyte quantities in megabytes so "1Gi" isn't mistaken
+		// for a very small value and +50% of it isn't lost to rounding.
+		switch unitPart {
+		case "Gi":
+			amount, unitPart = amount*1024, "Mi"
+		case "G":
+			amount, unitPart = amount*1000, "M"
+		}
+	}
+	if err != nil || amount < 64 {
+		return "256Mi"
+	}
+
+	return fmt.Sprintf("%d%s", int(float64(amount)*1.5), unitPart)
+}
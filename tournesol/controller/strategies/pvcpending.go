@@ -0,0 +1,66 @@
+package strategies
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+)
+
+func init() {
+	register("pvcpending", func() RemediationStrategy { return &pvcPendingStrategy{} })
+}
+
+// pvcPendingStrategy reports a PersistentVolumeClaim's storageClassName and
+// requested size when K8sGPT reports it's stuck Pending, which usually means
+// no StorageClass can satisfy the request as written. It does not edit the
+// claim.
+type pvcPendingStrategy struct{}
+
+func (s *pvcPendingStrategy) Name() string { return "pvcpending" }
+
+func (s *pvcPendingStrategy) Match(diag Diagnostic) bool {
+	lower := strings.ToLower(diag.Solution + " " + diag.Error)
+	return strings.Contains(lower, "pvc") && strings.Contains(lower, "pending") ||
+		strings.Contains(lower, "persistentvolumeclaim") && strings.Contains(lower, "pending") ||
+		strings.Contains(lower, "no persistent volumes available")
+}
+
+// Generate logs the PVC's current storage class and requested size; like
+// imagePullStrategy it doesn't know which StorageClass is actually available
+// in the cluster, so it surfaces the values a human needs to compare against
+// `kubectl get storageclass` rather than guessing a replacement.
+func (s *pvcPendingStrategy) Generate(ctx context.Context, _ Diagnostic, files map[string]string) ([]FileUpdate, error) {
+	log := logging.FromContext(ctx)
+	log.Debugw("running pvcpending strategy")
+
+	for name, content := range files {
+		if !isYAMLFile(name) || !strings.Contains(content, "kind: PersistentVolumeClaim") {
+			continue
+		}
+
+		storageClass := "(default)"
+		if idx := strings.Index(content, "storageClassName:"); idx >= 0 {
+			subContent := content[idx+len("storageClassName:"):]
+			if endIdx := strings.Index(subContent, "\n"); endIdx > 0 {
+				storageClass = strings.TrimSpace(subContent[:endIdx])
+			}
+		}
+
+		requestedSize := "(unknown)"
+		if idx := strings.Index(content, "storage:"); idx >= 0 {
+			subContent := content[idx+len("storage:"):]
+			if endIdx := strings.Index(subContent, "\n"); endIdx > 0 {
+				requestedSize = strings.TrimSpace(subContent[:endIdx])
+			}
+		}
+
+		log.Infow("PVC requests storage; confirm a StorageClass with that name exists and can satisfy the size",
+			"path", name, "requested_size", requestedSize, "storage_class", storageClass)
+	}
+
+	// No safe automated edit exists without knowing which StorageClasses are
+	// actually provisionable in the cluster, so this strategy intentionally
+	// returns no file updates.
+	return nil, nil
+}
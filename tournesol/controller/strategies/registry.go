@@ -0,0 +1,60 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mfernd/prof-tournesol/tournesol/controller/logging"
+)
+
+// Registry holds an ordered, configured subset of the built-in strategies
+// and dispatches a diagnostic to the first one that matches.
+type Registry struct {
+	strategies []RemediationStrategy
+}
+
+// NewRegistry builds a Registry from a comma-separated, ordered list of
+// strategy names (see DefaultOrder). Unknown names are rejected so a typo in
+// configuration fails fast at startup rather than silently skipping a
+// strategy.
+func NewRegistry(order string) (*Registry, error) {
+	var selected []RemediationStrategy
+
+	for _, name := range strings.Split(order, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		newStrategy, ok := builtins[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown remediation strategy %q", name)
+		}
+		selected = append(selected, newStrategy())
+	}
+
+	return &Registry{strategies: selected}, nil
+}
+
+// Generate finds the first configured strategy matching diag and returns its
+// proposed file updates. It returns (nil, nil) if no strategy matches.
+func (r *Registry) Generate(ctx context.Context, diag Diagnostic, files map[string]string) ([]FileUpdate, error) {
+	log := logging.FromContext(ctx)
+
+	for _, strategy := range r.strategies {
+		if !strategy.Match(diag) {
+			continue
+		}
+
+		log.Debugw("dispatching diagnostic to remediation strategy", "strategy", strategy.Name())
+		updates, err := strategy.Generate(ctx, diag, files)
+		if err != nil {
+			return nil, fmt.Errorf("strategy %q failed: %w", strategy.Name(), err)
+		}
+		return updates, nil
+	}
+
+	log.Debugw("no remediation strategy matched diagnostic")
+	return nil, nil
+}
@@ -0,0 +1,51 @@
+// Package strategies dispatches K8sGPT diagnostics to pluggable remediation
+// logic. Each RemediationStrategy owns one class of problem (OOMKilled,
+// CrashLoopBackOff, ...) and proposes YAML file edits for it; a Registry
+// tries a configured, ordered subset of them against a diagnostic until one
+// matches.
+package strategies
+
+import "context"
+
+// Diagnostic carries the K8sGPT-reported problem for a single resource,
+// mirroring the controller's own diagnostic type without importing it (to
+// keep this package dependency-free of package main).
+type Diagnostic struct {
+	Name      string
+	Kind      string
+	Namespace string
+	Error     string
+	Solution  string
+}
+
+// FileUpdate is a proposed change to a single file's contents, mirroring the
+// controller's own FileUpdate type.
+type FileUpdate struct {
+	Path    string
+	Content string
+}
+
+// RemediationStrategy proposes file edits for one class of K8sGPT diagnostic.
+type RemediationStrategy interface {
+	// Name identifies the strategy for configuration and logging, e.g. "oom".
+	Name() string
+	// Match reports whether this strategy applies to diag.
+	Match(diag Diagnostic) bool
+	// Generate proposes file updates for diag given the resource's current
+	// files. It is only called when Match(diag) is true.
+	Generate(ctx context.Context, diag Diagnostic, files map[string]string) ([]FileUpdate, error)
+}
+
+// DefaultOrder lists the built-in strategies in the order NewRegistry tries
+// them when REMEDIATION_STRATEGIES isn't set.
+const DefaultOrder = "oom,crashloopbackoff,imagepullbackoff,failedscheduling,pvcpending"
+
+// builtins maps strategy name to constructor, populated by each strategy's
+// init() so new strategies only need to be added in one place.
+var builtins = map[string]func() RemediationStrategy{}
+
+// register adds a strategy constructor to builtins. Called from the init()
+// of each strategy's file.
+func register(name string, newStrategy func() RemediationStrategy) {
+	builtins[name] = newStrategy
+}
@@ -0,0 +1,8 @@
+package strategies
+
+import "strings"
+
+// isYAMLFile reports whether name looks like a YAML manifest.
+func isYAMLFile(name string) bool {
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")
+}